@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// agendaSections are the sections of the agenda in display order, each
+// with the color role its heading is drawn in.
+var agendaSections = []struct {
+	Title string
+	Role  string
+}{
+	{dueOverdue, "overdue"},
+	{dueToday, "today"},
+	{dueTomorrow, "soon"},
+	{dueThisWeek, "soon"},
+	{dueLater, "later"},
+	{dueNone, ""},
+}
+
+// buildAgenda sorts todos into the agenda sections, soonest due and then
+// highest priority first, with undated todos last and ties in ID order.
+// Todos due more than days days from today are left out and counted;
+// days <= 0 means no limit.
+func buildAgenda(todos []Todo, days int) ([]todoGroup, int) {
+	today := startOfDay(clock())
+
+	sorted := append([]Todo(nil), todos...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case a.DueDate.Valid != b.DueDate.Valid:
+			return a.DueDate.Valid
+		case a.DueDate.Valid && !a.DueDate.Time.Equal(b.DueDate.Time):
+			return a.DueDate.Time.Before(b.DueDate.Time)
+		case a.Priority.Rank() != b.Priority.Rank():
+			return a.Priority.Rank() > b.Priority.Rank()
+		}
+		return a.ID < b.ID
+	})
+
+	bySection := map[string][]Todo{}
+	hidden := 0
+	for _, todo := range sorted {
+		class, until := classifyDue(todo.DueDate, today)
+		if days > 0 && todo.DueDate.Valid && until > days {
+			hidden++
+			continue
+		}
+		bySection[class] = append(bySection[class], todo)
+	}
+
+	groups := make([]todoGroup, len(agendaSections))
+	for i, section := range agendaSections {
+		groups[i] = todoGroup{Title: section.Title, Todos: bySection[section.Title]}
+	}
+	return groups, hidden
+}
+
+func cmdAgenda(days int) error {
+	todos, err := getTodos(todoFilter{Project: activeProjectID()})
+	if err != nil {
+		return err
+	}
+
+	groups, hidden := buildAgenda(todos, days)
+
+	counts := make([]string, len(groups))
+	for i, g := range groups {
+		counts[i] = fmt.Sprintf("%s %d", g.Title, len(g.Todos))
+	}
+
+	fmt.Printf("\nAgenda%s:\n", projectHeader())
+	fmt.Println(strings.Join(counts, " · "))
+	fmt.Println("---------------------------------------")
+
+	if len(todos) == 0 {
+		fmt.Println("No todos found")
+		return nil
+	}
+
+	columns, err := parseColumns("id,title,priority,category,due")
+	if err != nil {
+		return err
+	}
+
+	var tables []*Table
+	for _, g := range groups {
+		tables = append(tables, newTodoTable(g.Todos, columns))
+	}
+	alignWidths(tables)
+
+	first := true
+	for i, g := range groups {
+		if len(g.Todos) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+
+		heading := g.Title
+		if role := agendaSections[i].Role; role != "" {
+			heading = colorize(roleColor(role), heading)
+		}
+		fmt.Printf("%s (%d)\n", colorize(Bold, heading), len(g.Todos))
+		tables[i].Print()
+	}
+
+	if hidden > 0 {
+		fmt.Printf("\n%d more due after the next %d days\n", hidden, days)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setClock pins clock to t for the rest of the test.
+func setClock(t *testing.T, now time.Time) {
+	saved := clock
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = saved })
+}
+
+func TestClassifyDue(t *testing.T) {
+	// Friday
+	today := time.Date(2026, 10, 23, 0, 0, 0, 0, time.Local)
+	due := func(days int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 10, 23+days, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	tests := []struct {
+		name     string
+		due      sql.NullTime
+		want     string
+		wantDays int
+	}{
+		{name: "no date", due: sql.NullTime{}, want: dueNone},
+		{name: "yesterday", due: due(-1), want: dueOverdue, wantDays: -1},
+		{name: "last month", due: due(-30), want: dueOverdue, wantDays: -30},
+		{name: "today", due: due(0), want: dueToday},
+		{name: "tomorrow", due: due(1), want: dueTomorrow, wantDays: 1},
+		{name: "in six days", due: due(6), want: dueThisWeek, wantDays: 6},
+		{name: "in a week", due: due(7), want: dueLater, wantDays: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, days := classifyDue(tt.due, today)
+			if got != tt.want || days != tt.wantDays {
+				t.Errorf("classifyDue() = %q, %d, want %q, %d", got, days, tt.want, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestBuildAgenda(t *testing.T) {
+	setClock(t, time.Date(2026, 10, 23, 18, 30, 0, 0, time.Local))
+
+	due := func(days int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 10, 23+days, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+	todos := []Todo{
+		{ID: 1, Title: "Someday", Priority: PriorityLow},
+		{ID: 2, Title: "Late", Priority: PriorityLow, DueDate: due(-2)},
+		{ID: 3, Title: "Later low", Priority: PriorityLow, DueDate: due(10)},
+		{ID: 4, Title: "Today low", Priority: PriorityLow, DueDate: due(0)},
+		{ID: 5, Title: "Today high", Priority: PriorityHigh, DueDate: due(0)},
+		{ID: 6, Title: "Tomorrow", Priority: PriorityMedium, DueDate: due(1)},
+		{ID: 7, Title: "Next Tuesday", Priority: PriorityMedium, DueDate: due(4)},
+		{ID: 8, Title: "Far away", Priority: PriorityHigh, DueDate: due(60)},
+		{ID: 10, Title: "Undated low", Priority: PriorityLow},
+		{ID: 9, Title: "Undated high", Priority: PriorityHigh},
+	}
+
+	groups, hidden := buildAgenda(todos, 14)
+
+	want := map[string][]int{
+		dueOverdue:  {2},
+		dueToday:    {5, 4},
+		dueTomorrow: {6},
+		dueThisWeek: {7},
+		dueLater:    {3},
+		dueNone:     {9, 1, 10},
+	}
+	if len(groups) != len(agendaSections) {
+		t.Fatalf("buildAgenda() returned %d sections, want %d", len(groups), len(agendaSections))
+	}
+	for i, g := range groups {
+		if g.Title != agendaSections[i].Title {
+			t.Errorf("section %d = %q, want %q", i, g.Title, agendaSections[i].Title)
+		}
+		var ids []int
+		for _, todo := range g.Todos {
+			ids = append(ids, todo.ID)
+		}
+		if len(ids) != len(want[g.Title]) {
+			t.Errorf("section %q = %v, want %v", g.Title, ids, want[g.Title])
+			continue
+		}
+		for j := range ids {
+			if ids[j] != want[g.Title][j] {
+				t.Errorf("section %q = %v, want %v", g.Title, ids, want[g.Title])
+				break
+			}
+		}
+	}
+	if hidden != 1 {
+		t.Errorf("buildAgenda() hidden = %d, want 1", hidden)
+	}
+
+	if _, hidden := buildAgenda(todos, 0); hidden != 0 {
+		t.Errorf("buildAgenda() with no limit hid %d todos", hidden)
+	}
+}
+
+func TestCmdAgenda(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	setClock(t, time.Date(2026, 10, 23, 9, 0, 0, 0, time.Local))
+
+	insertTestTodo(t, "Pay rent", PriorityHigh, "home", "2026-10-22")
+	insertTestTodo(t, "Standup", PriorityMedium, "work", "2026-10-23")
+	insertTestTodo(t, "Read book", PriorityLow, "", "")
+	insertTestTodo(t, "Renew passport", PriorityLow, "", "2027-03-01")
+	done := insertTestTodo(t, "Already done", PriorityLow, "", "2026-10-23")
+	markTodoAsDone(int(done))
+
+	var err error
+	out := stripAnsi(captureOutput(func() { err = cmdAgenda(14) }))
+	if err != nil {
+		t.Fatalf("cmdAgenda() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Overdue 1 · Today 1 · Tomorrow 0 · This week 0 · Later 0 · No date 1",
+		"Overdue (1)",
+		"Today (1)",
+		"No date (1)",
+		"1 more due after the next 14 days",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdAgenda() output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Already done") || strings.Contains(out, "Tomorrow (") {
+		t.Errorf("cmdAgenda() shows done todos or empty sections:\n%s", out)
+	}
+	if strings.Index(out, "Pay rent") > strings.Index(out, "Standup") {
+		t.Errorf("cmdAgenda() sections out of order:\n%s", out)
+	}
+}
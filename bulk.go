@@ -0,0 +1,271 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// todoSelection picks the todos a bulk command acts on: explicit IDs,
+// filters, or both (filters then narrow the IDs).
+type todoSelection struct {
+	IDs      []int
+	Category string
+	Priority Priority
+	Done     bool
+	Pending  bool
+}
+
+type bulkOptions struct {
+	Force  bool // skip the confirmation prompt
+	Strict bool // abort if any ID is not found
+}
+
+func (s todoSelection) hasFilters() bool {
+	return s.Category != "" || s.Priority != "" || s.Done || s.Pending
+}
+
+// isSingle reports whether the selection is exactly one ID with no filters,
+// which the single-todo commands handle.
+func (s todoSelection) isSingle() bool {
+	return len(s.IDs) == 1 && !s.hasFilters()
+}
+
+func (s todoSelection) matches(todo Todo) bool {
+	if s.Category != "" && todo.Category != s.Category {
+		return false
+	}
+	if s.Priority != "" && todo.Priority != s.Priority {
+		return false
+	}
+	if s.Done && !todo.Done {
+		return false
+	}
+	if s.Pending && todo.Done {
+		return false
+	}
+	return true
+}
+
+// maxIDRange is the most IDs one range may cover, so a typo such as
+// 1-999999999 fails instead of selecting a billion IDs.
+const maxIDRange = 10000
+
+// parseIDList parses IDs given as separate arguments, comma lists and
+// inclusive ranges, e.g. "3" "5,6" "7-12". Duplicates are dropped.
+func parseIDList(args []string) ([]int, error) {
+	var ids []int
+	seen := map[int]bool{}
+
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, arg := range args {
+		for _, part := range strings.Split(arg, ",") {
+			if part == "" {
+				continue
+			}
+
+			if from, to, ok := strings.Cut(part, "-"); ok {
+				start, err1 := strconv.Atoi(from)
+				end, err2 := strconv.Atoi(to)
+				if err1 != nil || err2 != nil || start > end {
+					return nil, fmt.Errorf("invalid ID range %q", part)
+				}
+				if end-start >= maxIDRange {
+					return nil, fmt.Errorf("ID range %q covers more than %d IDs. Use filters such as --category to select more", part, maxIDRange)
+				}
+				for id := start; id <= end; id++ {
+					add(id)
+				}
+				continue
+			}
+
+			id, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ID %q", part)
+			}
+			add(id)
+		}
+	}
+
+	return ids, nil
+}
+
+// resolveSelection loads the selected todos, keeping filters to the active
+// project and context. IDs that don't exist are returned separately so the
+// caller can decide whether that's fatal.
+func resolveSelection(sel todoSelection) ([]Todo, []int, error) {
+	if len(sel.IDs) == 0 && !sel.hasFilters() {
+		return nil, nil, fmt.Errorf("no todos selected. Give IDs or a filter such as --category")
+	}
+
+	var candidates []Todo
+	var missing []int
+
+	if len(sel.IDs) == 0 {
+		// As in list, the active context fills in the filters not given;
+		// explicit IDs are taken as they are
+		if activeContext != nil {
+			if sel.Category == "" {
+				sel.Category = activeContext.Category
+			}
+			if sel.Priority == "" {
+				sel.Priority = activeContext.Priority
+			}
+		}
+
+		all, err := getTodos(todoFilter{ShowAll: true, Snoozed: true, Project: activeProjectID()})
+		if err != nil {
+			return nil, nil, err
+		}
+		candidates = all
+	} else {
+		for _, id := range sel.IDs {
+			todo, err := fetchTodo(db, id)
+			if err == sql.ErrNoRows {
+				missing = append(missing, id)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			candidates = append(candidates, *todo)
+		}
+	}
+
+	var todos []Todo
+	for _, todo := range candidates {
+		if sel.matches(todo) {
+			todos = append(todos, todo)
+		}
+	}
+
+	return todos, missing, nil
+}
+
+// prepareBulk resolves the selection, reports missing IDs, previews the
+// affected todos and asks for confirmation. It returns nil todos when there
+// is nothing to do or the user declined.
+func prepareBulk(sel todoSelection, opts bulkOptions, action string) ([]Todo, error) {
+	todos, missing, err := resolveSelection(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missing) > 0 && opts.Strict {
+		return nil, fmt.Errorf("todo #%d not found", missing[0])
+	}
+	for _, id := range missing {
+		fmt.Printf("%s todo #%d not found\n", colorize(Red, "Error:"), id)
+	}
+
+	if len(todos) == 0 {
+		fmt.Println("No todos found")
+		return nil, nil
+	}
+
+	todoTable(todos).Print()
+
+	if !opts.Force && !confirm(fmt.Sprintf("%s %d todos?", action, len(todos))) {
+		fmt.Println("Cancelled")
+		return nil, nil
+	}
+
+	return todos, nil
+}
+
+// applyBulk runs op on every todo in a single transaction and journal batch,
+// so one undo reverts the whole command.
+func applyBulk(command string, todos []Todo, op func(b *journalBatch, todo Todo) error) error {
+	return withJournal(command, func(b *journalBatch) error {
+		for _, todo := range todos {
+			if err := op(b, todo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func cmdBulkStatus(sel todoSelection, done bool, opts bulkOptions) error {
+	status, action := 0, "Mark as not done"
+	if done {
+		status, action = 1, "Mark as done"
+	}
+
+	todos, err := prepareBulk(sel, opts, action)
+	if err != nil || todos == nil {
+		return err
+	}
+
+	err = applyBulk(statusCommand(status), todos, func(b *journalBatch, todo Todo) error {
+		return setTodoStatusIn(b, todo.ID, status)
+	})
+	if err != nil {
+		return err
+	}
+
+	if done {
+		fmt.Printf("%s Marked %d todos as done\n", colorize(Green, "✓"), len(todos))
+	} else {
+		fmt.Printf("%s Marked %d todos as not done\n", colorize(Blue, "x"), len(todos))
+	}
+	return nil
+}
+
+func cmdBulkDelete(sel todoSelection, opts bulkOptions) error {
+	todos, err := prepareBulk(sel, opts, "Delete")
+	if err != nil || todos == nil {
+		return err
+	}
+
+	err = applyBulk("delete", todos, func(b *journalBatch, todo Todo) error {
+		return deleteTodoIn(b, todo.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Deleted %d todos\n", colorize(Red, "✗"), len(todos))
+	return nil
+}
+
+func cmdBulkEdit(sel todoSelection, title string, priority Priority, category, dueDate string, opts bulkOptions) error {
+	if dueDate != "" {
+		_, err := parseDate(dueDate)
+		if err != nil {
+			return fmt.Errorf("invalid date format. Use YYYY-MM-DD")
+		}
+	}
+
+	if priority != "" {
+		if err := currentPriorities().check(priority); err != nil {
+			return err
+		}
+	}
+
+	if title == "" && priority == "" && category == "" && dueDate == "" {
+		return fmt.Errorf("nothing to update. Use --title, --priority, --category, or --due")
+	}
+
+	todos, err := prepareBulk(sel, opts, "Update")
+	if err != nil || todos == nil {
+		return err
+	}
+
+	err = applyBulk("edit", todos, func(b *journalBatch, todo Todo) error {
+		return updateTodoIn(b, todo.ID, title, priority, category, dueDate)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated %d todos\n", len(todos))
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseIDList(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []int
+		wantErr bool
+	}{
+		{name: "single ID", args: []string{"3"}, want: []int{3}},
+		{name: "separate IDs", args: []string{"3", "5"}, want: []int{3, 5}},
+		{name: "range", args: []string{"7-9"}, want: []int{7, 8, 9}},
+		{name: "comma list", args: []string{"4,6"}, want: []int{4, 6}},
+		{name: "mixed", args: []string{"3", "5,7-8"}, want: []int{3, 5, 7, 8}},
+		{name: "duplicates dropped", args: []string{"3", "2-4"}, want: []int{3, 2, 4}},
+		{name: "trailing comma", args: []string{"3,"}, want: []int{3}},
+		{name: "no args", args: nil, want: nil},
+		{name: "not a number", args: []string{"abc"}, wantErr: true},
+		{name: "reversed range", args: []string{"9-7"}, wantErr: true},
+		{name: "open range", args: []string{"7-"}, wantErr: true},
+		{name: "huge range", args: []string{"1-999999999"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIDList(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseIDList(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseIDList(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseIDList(%q) = %v, want %v", tt.args, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	if got, err := parseIDList([]string{"1-10000"}); err != nil || len(got) != maxIDRange {
+		t.Errorf("parseIDList(1-10000) = %d IDs, %v, want %d", len(got), err, maxIDRange)
+	}
+}
+
+func TestResolveSelection(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityHigh, "old", "")
+	insertTestTodo(t, "Task 2", PriorityLow, "old", "")
+	insertTestTodo(t, "Task 3", PriorityHigh, "new", "")
+	markTodoAsDone(2)
+
+	tests := []struct {
+		name        string
+		sel         todoSelection
+		wantIDs     []int
+		wantMissing []int
+		wantErr     bool
+	}{
+		{name: "IDs with missing", sel: todoSelection{IDs: []int{1, 9, 3}}, wantIDs: []int{1, 3}, wantMissing: []int{9}},
+		{name: "category and done", sel: todoSelection{Category: "old", Done: true}, wantIDs: []int{2}},
+		{name: "category and pending", sel: todoSelection{Category: "old", Pending: true}, wantIDs: []int{1}},
+		{name: "priority narrows IDs", sel: todoSelection{IDs: []int{1, 2, 3}, Priority: PriorityHigh}, wantIDs: []int{1, 3}},
+		{name: "empty selection", sel: todoSelection{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, missing, err := resolveSelection(tt.sel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var ids []int
+			for _, todo := range todos {
+				ids = append(ids, todo.ID)
+			}
+			if len(ids) != len(tt.wantIDs) || len(missing) != len(tt.wantMissing) {
+				t.Fatalf("resolveSelection() = %v, missing %v; want %v, missing %v", ids, missing, tt.wantIDs, tt.wantMissing)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("resolveSelection() = %v, want %v", ids, tt.wantIDs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCmdBulkStatus(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	for i := 0; i < 4; i++ {
+		insertTestTodo(t, "Task", PriorityMedium, "", "")
+	}
+
+	err := cmdBulkStatus(todoSelection{IDs: []int{1, 2, 99, 4}}, true, bulkOptions{Force: true})
+	if err != nil {
+		t.Fatalf("cmdBulkStatus() error = %v", err)
+	}
+
+	count, _ := countCompletedTodos()
+	if count != 3 {
+		t.Errorf("completed count = %d, want 3", count)
+	}
+
+	// The whole command is one journal batch
+	undoJournal(1)
+	count, _ = countCompletedTodos()
+	if count != 0 {
+		t.Errorf("completed count after undo = %d, want 0", count)
+	}
+}
+
+func TestCmdBulkStatus_Strict(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task", PriorityMedium, "", "")
+
+	err := cmdBulkStatus(todoSelection{IDs: []int{1, 99}}, true, bulkOptions{Force: true, Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "#99 not found") {
+		t.Errorf("cmdBulkStatus() error = %v, want #99 not found", err)
+	}
+
+	todo, _ := getTodoByID(1)
+	if todo.Done {
+		t.Error("todo #1 should not change when --strict aborts")
+	}
+}
+
+func TestCmdBulkDelete(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityMedium, "old", "")
+	insertTestTodo(t, "Task 2", PriorityMedium, "old", "")
+	insertTestTodo(t, "Task 3", PriorityMedium, "new", "")
+	markTodoAsDone(1)
+	markTodoAsDone(3)
+
+	err := cmdBulkDelete(todoSelection{Category: "old", Done: true}, bulkOptions{Force: true})
+	if err != nil {
+		t.Fatalf("cmdBulkDelete() error = %v", err)
+	}
+
+	count, _ := countAllTodos()
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+	if _, err := getTodoByID(1); err == nil {
+		t.Error("todo #1 should have been deleted")
+	}
+}
+
+func TestCmdBulkEdit(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityLow, "", "")
+	insertTestTodo(t, "Task 2", PriorityLow, "", "")
+	insertTestTodo(t, "Task 3", PriorityLow, "", "")
+
+	err := cmdBulkEdit(todoSelection{IDs: []int{1, 3}}, "", PriorityHigh, "", "", bulkOptions{Force: true})
+	if err != nil {
+		t.Fatalf("cmdBulkEdit() error = %v", err)
+	}
+
+	for id, want := range map[int]Priority{1: PriorityHigh, 2: PriorityLow, 3: PriorityHigh} {
+		todo, _ := getTodoByID(id)
+		if todo.Priority != want {
+			t.Errorf("todo #%d priority = %v, want %v", id, todo.Priority, want)
+		}
+	}
+
+	err = cmdBulkEdit(todoSelection{IDs: []int{1, 3}}, "", Priority("urgent"), "", "", bulkOptions{Force: true})
+	if err == nil || !strings.Contains(err.Error(), "invalid priority") {
+		t.Errorf("cmdBulkEdit() error = %v, want invalid priority", err)
+	}
+}
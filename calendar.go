@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// calendarCell is how wide one day is drawn: a marker either side of the
+// day number, then the number of todos due, e.g. " 5 (2)", "[19](1)" or
+// " 7 9+" for more than nine.
+const calendarCell = 7
+
+// parseMonth reads a YYYY-MM month, or returns the current month for "".
+func parseMonth(s string) (time.Time, error) {
+	if s == "" {
+		now := clock()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local), nil
+	}
+
+	month, err := time.ParseInLocation("2006-01", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q. Use YYYY-MM", s)
+	}
+	return month, nil
+}
+
+// dueByDay groups the todos due in month by day of the month.
+func dueByDay(todos []Todo, month time.Time) map[int][]Todo {
+	days := map[int][]Todo{}
+	for _, todo := range todos {
+		if !todo.DueDate.Valid {
+			continue
+		}
+		y, m, d := todo.DueDate.Time.UTC().Date()
+		if y == month.Year() && m == month.Month() {
+			days[d] = append(days[d], todo)
+		}
+	}
+	return days
+}
+
+// highestPriority returns the most urgent priority among todos.
+func highestPriority(todos []Todo) Priority {
+	var top Priority
+	for _, todo := range todos {
+		if top == "" || todo.Priority.Rank() > top.Rank() {
+			top = todo.Priority
+		}
+	}
+	return top
+}
+
+// renderCalendar draws a Monday-first month grid. Days with todos due show
+// their count in the color of the highest priority due; today is bracketed
+// and, with color on, shown in reverse video.
+func renderCalendar(month time.Time, todos []Todo, today time.Time, expand bool) []string {
+	due := dueByDay(todos, month)
+	width := 7*calendarCell + 6
+
+	title := month.Format("January 2006")
+	pad := max((width-len(title))/2, 0)
+	lines := []string{strings.Repeat(" ", pad) + colorize(Bold, title)}
+
+	var names []string
+	for _, name := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		names = append(names, fmt.Sprintf(" %-*s", calendarCell-1, name))
+	}
+	lines = append(lines, strings.TrimRight(strings.Join(names, " "), " "))
+
+	first := month
+	offset := (int(first.Weekday()) + 6) % 7
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	cells := make([]string, offset)
+	for i := range cells {
+		cells[i] = strings.Repeat(" ", calendarCell)
+	}
+
+	for day := 1; day <= daysInMonth; day++ {
+		isToday := today.Year() == month.Year() && today.Month() == month.Month() && today.Day() == day
+
+		left, right := " ", " "
+		if isToday {
+			left, right = "[", "]"
+		}
+
+		count := ""
+		if n := len(due[day]); n > 9 {
+			count = "9+"
+		} else if n > 0 {
+			count = fmt.Sprintf("(%d)", n)
+		}
+
+		cell := fmt.Sprintf("%s%2d%s%-*s", left, day, right, calendarCell-4, count)
+		if len(due[day]) > 0 {
+			cell = colorize(priorityColor(highestPriority(due[day])), cell)
+		}
+		if isToday {
+			cell = colorize(Reverse, cell)
+		}
+		cells = append(cells, cell)
+	}
+
+	for start := 0; start < len(cells); start += 7 {
+		end := min(start+7, len(cells))
+		lines = append(lines, strings.TrimRight(strings.Join(cells[start:end], " "), " "))
+	}
+
+	scale := currentPriorities()
+	legend := make([]string, len(scale.Levels))
+	for i, level := range scale.Levels {
+		legend[i] = colorize(scale.color(level), string(level))
+	}
+	lines = append(lines, "", strings.Join(legend, "  ")+"   [dd] today")
+
+	if expand {
+		for day := 1; day <= daysInMonth; day++ {
+			if len(due[day]) == 0 {
+				continue
+			}
+			date := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.Local)
+			lines = append(lines, "", colorize(Bold, date.Format("Mon Jan 02")))
+			for _, todo := range due[day] {
+				lines = append(lines, fmt.Sprintf("  #%-4d %s %s", todo.ID,
+					colorize(priorityColor(todo.Priority), fmt.Sprintf("%-6s", todo.Priority)), todo.Title))
+			}
+		}
+	}
+
+	return lines
+}
+
+func cmdCalendar(month string, expand bool) error {
+	start, err := parseMonth(month)
+	if err != nil {
+		return err
+	}
+
+	todos, err := getTodos(todoFilter{Project: activeProjectID()})
+	if err != nil {
+		return err
+	}
+
+	if err := sortTodos(todos, "priority"); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	for _, line := range renderCalendar(start, todos, clock(), expand) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMonth(t *testing.T) {
+	setClock(t, time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local))
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: "2026-10"},
+		{input: "2026-11", want: "2026-11"},
+		{input: "2026-13", wantErr: true},
+		{input: "November", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMonth(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMonth(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Format("2006-01") != tt.want {
+			t.Errorf("parseMonth(%q) = %s, want %s", tt.input, got.Format("2006-01"), tt.want)
+		}
+	}
+}
+
+func TestRenderCalendar(t *testing.T) {
+	due := func(date string) sql.NullTime {
+		d, _ := time.Parse("2006-01-02", date)
+		return sql.NullTime{Time: d, Valid: true}
+	}
+	todos := []Todo{
+		{ID: 1, Title: "Dentist", Priority: PriorityLow, DueDate: due("2026-11-03")},
+		{ID: 2, Title: "Tax return", Priority: PriorityHigh, DueDate: due("2026-11-03")},
+		{ID: 3, Title: "Pay rent", Priority: PriorityMedium, DueDate: due("2026-11-30")},
+		{ID: 4, Title: "Other month", Priority: PriorityHigh, DueDate: due("2026-12-01")},
+		{ID: 5, Title: "Undated", Priority: PriorityHigh},
+	}
+	month := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)
+	today := time.Date(2026, 11, 19, 9, 0, 0, 0, time.Local)
+
+	lines := renderCalendar(month, todos, today, false)
+	plain := stripAnsi(strings.Join(lines, "\n"))
+
+	// November 2026 starts on a Sunday, so the first week has one day
+	want := []string{
+		"November 2026",
+		" Mo      Tu      We      Th      Fr      Sa      Su",
+		strings.Repeat(" ", 6*8) + "  1",
+		"  2       3 (2)   4       5       6       7       8",
+		" 16      17      18     [19]     20      21      22",
+		" 30 (1)",
+	}
+	for _, w := range want {
+		if !strings.Contains(plain, w) {
+			t.Errorf("renderCalendar() missing %q:\n%s", w, plain)
+		}
+	}
+	if strings.Contains(plain, "Other month") {
+		t.Errorf("renderCalendar() lists titles without expand:\n%s", plain)
+	}
+
+	// Day 3 is colored by its most urgent todo
+	joined := strings.Join(lines, "\n")
+	if !strings.Contains(joined, string(priorityColor(PriorityHigh))+"  3 (2)") {
+		t.Errorf("renderCalendar() should color day 3 as high priority:\n%q", joined)
+	}
+
+	expanded := stripAnsi(strings.Join(renderCalendar(month, todos, today, true), "\n"))
+	for _, w := range []string{"Tue Nov 03", "#1    low    Dentist", "#2    high   Tax return", "Mon Nov 30"} {
+		if !strings.Contains(expanded, w) {
+			t.Errorf("renderCalendar() expanded missing %q:\n%s", w, expanded)
+		}
+	}
+	if strings.Contains(expanded, "Other month") || strings.Contains(expanded, "Undated") {
+		t.Errorf("renderCalendar() lists todos outside the month:\n%s", expanded)
+	}
+}
+
+func TestRenderCalendar_RowWidth(t *testing.T) {
+	// A todo due every day of the full weeks, and more than nine on two days
+	var todos []Todo
+	for day := 2; day <= 29; day++ {
+		n := 1
+		if day == 4 || day == 18 {
+			n = 12
+		}
+		for i := 0; i < n; i++ {
+			todos = append(todos, Todo{ID: len(todos) + 1, Priority: PriorityMedium,
+				DueDate: sql.NullTime{Time: time.Date(2026, 11, day, 0, 0, 0, 0, time.UTC), Valid: true}})
+		}
+	}
+	month := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)
+	today := time.Date(2026, 11, 19, 9, 0, 0, 0, time.Local)
+
+	lines := renderCalendar(month, todos, today, false)
+	if !strings.Contains(stripAnsi(lines[3]), "  4 9+ ") {
+		t.Errorf("renderCalendar() should show more than nine as 9+:\n%s", stripAnsi(lines[3]))
+	}
+	// Rows 3 to 6 are the full weeks from the 2nd to the 29th
+	for _, line := range lines[3:7] {
+		if got, want := displayWidth(stripAnsi(line)), 7*calendarCell+6; got != want {
+			t.Errorf("row %q is %d wide, want %d", stripAnsi(line), got, want)
+		}
+	}
+}
+
+func TestCmdCalendar(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	setClock(t, time.Date(2026, 11, 19, 9, 0, 0, 0, time.Local))
+
+	insertTestTodo(t, "Tax return", PriorityHigh, "", "2026-11-03")
+
+	var err error
+	out := stripAnsi(captureOutput(func() { err = cmdCalendar("", true) }))
+	if err != nil {
+		t.Fatalf("cmdCalendar() error = %v", err)
+	}
+	if !strings.Contains(out, "November 2026") || !strings.Contains(out, "Tax return") {
+		t.Errorf("cmdCalendar() output:\n%s", out)
+	}
+
+	if err := cmdCalendar("soon", false); err == nil {
+		t.Errorf("cmdCalendar() with invalid month should fail")
+	}
+}
@@ -2,32 +2,66 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
-func formatDueDate(dueDate sql.NullTime) string {
+// clock returns the current time. Tests replace it to pin down "today".
+var clock = time.Now
+
+// Due date classes, in the order agenda shows them.
+const (
+	dueOverdue  = "Overdue"
+	dueToday    = "Today"
+	dueTomorrow = "Tomorrow"
+	dueThisWeek = "This week"
+	dueLater    = "Later"
+	dueNone     = "No date"
+)
+
+// classifyDue places a due date relative to today and returns how many
+// calendar days away it is, negative when overdue.
+func classifyDue(dueDate sql.NullTime, today time.Time) (string, int) {
 	if !dueDate.Valid {
-		return ""
+		return dueNone, 0
 	}
 
-	due := dueDate.Time
-	today := time.Now().Truncate(24 * time.Hour)
-	dueDay := due.Truncate(24 * time.Hour)
+	days := daysBetween(today, dueDay(dueDate.Time))
+	switch {
+	case days < 0:
+		return dueOverdue, days
+	case days == 0:
+		return dueToday, days
+	case days == 1:
+		return dueTomorrow, days
+	case days < 7:
+		return dueThisWeek, days
+	}
+	return dueLater, days
+}
 
-	daysUntil := int(dueDay.Sub(today).Hours() / 24)
+func formatDueDate(dueDate sql.NullTime) string {
+	if !dueDate.Valid {
+		return ""
+	}
 
-	dateStr := due.Format("2006-01-02")
+	class, days := classifyDue(dueDate, startOfDay(clock()))
+	dateStr := dueDate.Time.Format(cfg.Get("date.format"))
 
-	if daysUntil < 0 {
-		return colorize(Red, dateStr+" (OVERDUE)")
-	} else if daysUntil == 0 {
-		return colorize(Red, dateStr+" (TODAY)")
-	} else if daysUntil == 1 {
-		return colorize(Yellow, dateStr+" (tomorrow)")
-	} else if daysUntil <= 3 {
-		return colorize(Yellow, dateStr)
+	switch {
+	case class == dueOverdue:
+		return colorize(roleColor("overdue"), dateStr+" (OVERDUE)")
+	case class == dueToday:
+		return colorize(roleColor("today"), dateStr+" (TODAY)")
+	case class == dueTomorrow:
+		return colorize(roleColor("soon"), dateStr+" (tomorrow)")
+	case days <= 3:
+		return colorize(roleColor("soon"), dateStr)
 	}
-	return colorize(Green, dateStr)
+	return colorize(roleColor("later"), dateStr)
 }
 
 // Color represents an ANSI color code
@@ -35,30 +69,176 @@ type Color string
 
 // ANSI color codes
 const (
-	Reset  Color = "\033[0m"
-	Red    Color = "\033[31m"
-	Green  Color = "\033[32m"
-	Yellow Color = "\033[33m"
-	Blue   Color = "\033[34m"
-	Purple Color = "\033[35m"
-	Cyan   Color = "\033[36m"
-	Gray   Color = "\033[90m"
-	Bold   Color = "\033[1m"
+	Reset   Color = "\033[0m"
+	Red     Color = "\033[31m"
+	Green   Color = "\033[32m"
+	Yellow  Color = "\033[33m"
+	Blue    Color = "\033[34m"
+	Purple  Color = "\033[35m"
+	Cyan    Color = "\033[36m"
+	Gray    Color = "\033[90m"
+	Bold    Color = "\033[1m"
+	Reverse Color = "\033[7m"
 )
 
+// colorEnabled turns colorize into a no-op when false.
+var colorEnabled = true
+
 func colorize(color Color, text string) string {
+	if !colorEnabled || color == "" {
+		return text
+	}
 	return string(color) + text + string(Reset)
 }
 
-func priorityColor(p Priority) Color {
-	switch p {
-	case PriorityHigh:
-		return Red
-	case PriorityMedium:
-		return Yellow
-	case PriorityLow:
-		return Green
-	default:
-		return Reset
+// isTerminal reports whether f is attached to a terminal rather than a
+// file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
 	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor decides whether to emit color for a --color mode. "auto" colors
+// only a terminal and honors NO_COLOR; "always" and "never" are absolute.
+func useColor(mode string, noColor, terminal bool) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "", "auto":
+		return terminal && !noColor, nil
+	}
+	return false, fmt.Errorf("invalid color mode %q. Use auto, always or never", mode)
+}
+
+// Theme maps semantic roles to colors.
+type Theme map[string]Color
+
+// colorRoles are the parts of the output a theme can color.
+var colorRoles = []string{
+	"priority_high", "priority_medium", "priority_low",
+	"overdue", "today", "soon", "later",
+	"done", "pending",
+}
+
+var themes = map[string]Theme{
+	"default": {
+		"priority_high":   Red,
+		"priority_medium": Yellow,
+		"priority_low":    Green,
+		"overdue":         Red,
+		"today":           Red,
+		"soon":            Yellow,
+		"later":           Green,
+		"done":            Green,
+		"pending":         Yellow,
+	},
+	"vivid": {
+		"priority_high":   "\033[1m\033[38;5;196m",
+		"priority_medium": "\033[38;5;214m",
+		"priority_low":    "\033[38;5;41m",
+		"overdue":         "\033[1m\033[38;5;196m",
+		"today":           "\033[38;5;202m",
+		"soon":            "\033[38;5;220m",
+		"later":           "\033[38;5;75m",
+		"done":            "\033[38;5;41m",
+		"pending":         "\033[38;5;220m",
+	},
+	"none": {},
+}
+
+// activeTheme is the theme in use; main replaces it from the config.
+var activeTheme = themes["default"]
+
+// roleColor returns the color for a role, or no color if the theme leaves
+// it unset.
+func roleColor(role string) Color {
+	return activeTheme[role]
+}
+
+func themeNames() []string {
+	return []string{"default", "vivid", "none"}
+}
+
+// loadTheme starts from a named built-in theme and applies per-role
+// overrides given as color specs.
+func loadTheme(name string, overrides map[string]string) (Theme, error) {
+	base, ok := themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q. Use %s", name, strings.Join(themeNames(), ", "))
+	}
+
+	theme := Theme{}
+	for role, color := range base {
+		theme[role] = color
+	}
+
+	for role, spec := range overrides {
+		if spec == "" {
+			continue
+		}
+		color, err := parseColorSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("theme.%s: %v", role, err)
+		}
+		theme[role] = color
+	}
+
+	return theme, nil
+}
+
+var namedColors = map[string]Color{
+	"red":    Red,
+	"green":  Green,
+	"yellow": Yellow,
+	"blue":   Blue,
+	"purple": Purple,
+	"cyan":   Cyan,
+	"gray":   Gray,
+	"bold":   Bold,
+	"none":   "",
+}
+
+// parseColorSpec turns a space-separated list of color names, 256-color
+// indexes (0-255) and truecolor hex values (#rrggbb) into an ANSI sequence,
+// e.g. "bold #ff8800" or "208".
+func parseColorSpec(spec string) (Color, error) {
+	var color Color
+
+	for _, part := range strings.Fields(strings.ToLower(spec)) {
+		if named, ok := namedColors[part]; ok {
+			color += named
+			continue
+		}
+
+		if hex, ok := strings.CutPrefix(part, "#"); ok && len(hex) == 6 {
+			rgb, err := strconv.ParseUint(hex, 16, 32)
+			if err == nil {
+				color += Color(fmt.Sprintf("\033[38;2;%d;%d;%dm", rgb>>16, rgb>>8&0xff, rgb&0xff))
+				continue
+			}
+		}
+
+		if n, err := strconv.Atoi(part); err == nil && n >= 0 && n <= 255 {
+			color += Color(fmt.Sprintf("\033[38;5;%dm", n))
+			continue
+		}
+
+		return "", fmt.Errorf("invalid color %q. Use a name, 0-255 or #rrggbb", part)
+	}
+
+	return color, nil
+}
+
+func validateColorSpec(spec string) error {
+	_, err := parseColorSpec(spec)
+	return err
+}
+
+func priorityColor(p Priority) Color {
+	return currentPriorities().color(p)
 }
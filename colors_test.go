@@ -83,7 +83,9 @@ func TestPriorityColor(t *testing.T) {
 }
 
 func TestFormatDueDate(t *testing.T) {
-	today := time.Now().Truncate(24 * time.Hour)
+	// Due dates are stored as midnight UTC on the local calendar date
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name     string
@@ -153,3 +155,113 @@ func TestFormatDueDate(t *testing.T) {
 		})
 	}
 }
+
+func TestColorize_Disabled(t *testing.T) {
+	colorEnabled = false
+	defer func() { colorEnabled = true }()
+
+	if got := colorize(Red, "error"); got != "error" {
+		t.Errorf("colorize() with color disabled = %q, want %q", got, "error")
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		mode     string
+		noColor  bool
+		terminal bool
+		want     bool
+		wantErr  bool
+	}{
+		{mode: "auto", terminal: true, want: true},
+		{mode: "auto", terminal: false, want: false},
+		{mode: "auto", noColor: true, terminal: true, want: false},
+		{mode: "", terminal: true, want: true},
+		{mode: "always", noColor: true, terminal: false, want: true},
+		{mode: "never", terminal: true, want: false},
+		{mode: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := useColor(tt.mode, tt.noColor, tt.terminal)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("useColor(%q, %v, %v) error = %v, wantErr %v", tt.mode, tt.noColor, tt.terminal, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("useColor(%q, %v, %v) = %v, want %v", tt.mode, tt.noColor, tt.terminal, got, tt.want)
+		}
+	}
+}
+
+func TestParseColorSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Color
+		wantErr bool
+	}{
+		{spec: "red", want: Red},
+		{spec: "Bold Red", want: Bold + Red},
+		{spec: "208", want: "\033[38;5;208m"},
+		{spec: "#ff8800", want: "\033[38;2;255;136;0m"},
+		{spec: "bold #00ff00", want: Bold + "\033[38;2;0;255;0m"},
+		{spec: "none", want: ""},
+		{spec: "256", wantErr: true},
+		{spec: "#ff88", wantErr: true},
+		{spec: "#gggggg", wantErr: true},
+		{spec: "magenta", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseColorSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseColorSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseColorSpec(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	theme, err := loadTheme("default", map[string]string{"overdue": "#ff0000", "priority_low": "244"})
+	if err != nil {
+		t.Fatalf("loadTheme() error = %v", err)
+	}
+	if theme["overdue"] != "\033[38;2;255;0;0m" {
+		t.Errorf("overdue = %q, want truecolor red", theme["overdue"])
+	}
+	if theme["priority_low"] != "\033[38;5;244m" {
+		t.Errorf("priority_low = %q, want 256-color 244", theme["priority_low"])
+	}
+	if theme["today"] != Red {
+		t.Errorf("today = %q, want default %q", theme["today"], Red)
+	}
+	if themes["default"]["overdue"] != Red {
+		t.Error("loadTheme() must not modify the built-in theme")
+	}
+
+	if _, err := loadTheme("neon", nil); err == nil {
+		t.Error("loadTheme() with unknown theme should fail")
+	}
+	if _, err := loadTheme("default", map[string]string{"done": "sparkly"}); err == nil {
+		t.Error("loadTheme() with invalid color should fail")
+	}
+}
+
+func TestThemeAppliesToOutput(t *testing.T) {
+	orig := activeTheme
+	defer func() { activeTheme = orig }()
+
+	activeTheme, _ = loadTheme("none", nil)
+	if got := priorityColor(PriorityHigh); got != "" {
+		t.Errorf("priorityColor() with none theme = %q, want no color", got)
+	}
+
+	yesterday := sql.NullTime{Time: time.Now().AddDate(0, 0, -1), Valid: true}
+	if got := formatDueDate(yesterday); strings.Contains(got, "\033[") {
+		t.Errorf("formatDueDate() with none theme = %q, want no escape codes", got)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// todoColumn is one column list can show. Flexible columns shrink to fit
+// the terminal; the rest keep their natural width.
+type todoColumn struct {
+	Name     string
+	Header   string
+	Align    Alignment
+	Flexible bool
+	Value    func(todo Todo) string
+
+	// Prepare, if set, replaces Value for columns read from other tables:
+	// it loads the values of all todos in a table at once.
+	Prepare func(todos []Todo) func(todo Todo) string
+}
+
+// todoColumnDefs is filled in by init because the column values read
+// settings, and the list.columns setting in turn validates column names.
+var todoColumnDefs []todoColumn
+
+func init() {
+	todoColumnDefs = []todoColumn{
+		{Name: "id", Header: "ID", Align: AlignRight, Value: func(todo Todo) string {
+			return fmt.Sprintf("%d", todo.ID)
+		}},
+		{Name: "done", Header: "✓", Value: func(todo Todo) string {
+			if todo.Done {
+				return colorize(roleColor("done"), "✓")
+			}
+			return " "
+		}},
+		{Name: "title", Header: "Title", Flexible: true, Value: func(todo Todo) string {
+			return todo.Title
+		}},
+		{Name: "priority", Header: "Priority", Value: func(todo Todo) string {
+			return colorize(priorityColor(todo.Priority), string(todo.Priority))
+		}},
+		{Name: "category", Header: "Category", Flexible: true, Value: func(todo Todo) string {
+			return todo.Category
+		}},
+		{Name: "urgency", Header: "Urgency", Align: AlignRight, Value: func(todo Todo) string {
+			if todo.Done {
+				return ""
+			}
+			return fmt.Sprintf("%.1f", currentUrgencyWeights().score(todo, clock()))
+		}},
+		{Name: "state", Header: "State", Value: func(todo Todo) string {
+			return currentWorkflow().stateOf(todo)
+		}},
+		{Name: "due", Header: "Due", Value: func(todo Todo) string {
+			return formatDueDate(todo.DueDate)
+		}},
+		{Name: "created", Header: "Created", Value: func(todo Todo) string {
+			return todo.CreatedAt.Local().Format(cfg.Get("date.format"))
+		}},
+		{Name: "completed", Header: "Completed", Value: func(todo Todo) string {
+			if !todo.CompletedAt.Valid {
+				return ""
+			}
+			return todo.CompletedAt.Time.Local().Format(cfg.Get("date.format"))
+		}},
+		{Name: "snoozed", Header: "Snoozed Until", Value: func(todo Todo) string {
+			if !isSnoozed(todo, clock()) {
+				return ""
+			}
+			return formatSnooze(todo.WaitUntil.Time)
+		}},
+		{Name: "age", Header: "Age", Align: AlignRight, Value: func(todo Todo) string {
+			return formatAge(time.Since(todo.CreatedAt))
+		}},
+		{Name: "spent", Header: "Spent", Align: AlignRight, Prepare: func(todos []Todo) func(Todo) string {
+			spent, err := timeSpentOn(todos, clock())
+			return func(todo Todo) string {
+				if err != nil || spent[todo.ID] < time.Minute {
+					return ""
+				}
+				return formatSpent(spent[todo.ID])
+			}
+		}},
+		{Name: "project", Header: "Project", Prepare: func(todos []Todo) func(Todo) string {
+			names, err := projectNames()
+			return func(todo Todo) string {
+				if todo.ProjectID == 0 {
+					return ""
+				}
+				if name, ok := names[todo.ProjectID]; ok && err == nil {
+					return name
+				}
+				return fmt.Sprintf("#%d", todo.ProjectID)
+			}
+		}},
+		{Name: "estimate", Header: "Estimate", Align: AlignRight, Value: func(todo Todo) string {
+			return estimateOf(todo).Display()
+		}},
+		{Name: "notes", Header: "Notes", Flexible: true, Value: func(todo Todo) string {
+			return summarizeNotes(todo.Notes)
+		}},
+	}
+}
+
+const defaultColumns = "id,done,title,priority,category,due"
+
+func columnNames() []string {
+	names := make([]string, len(todoColumnDefs))
+	for i, c := range todoColumnDefs {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func lookupColumn(name string) (todoColumn, bool) {
+	for _, c := range todoColumnDefs {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return todoColumn{}, false
+}
+
+// parseColumns reads a comma-separated column list. A column may carry an
+// alignment suffix, as in "id,title,age:left".
+func parseColumns(spec string) ([]todoColumn, error) {
+	var columns []todoColumn
+	for _, field := range strings.Split(spec, ",") {
+		name, align, hasAlign := strings.Cut(strings.TrimSpace(field), ":")
+		if name == "" {
+			continue
+		}
+
+		c, ok := lookupColumn(strings.ToLower(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q. Use %s", name, strings.Join(columnNames(), ", "))
+		}
+
+		if hasAlign {
+			switch align {
+			case "left", "l":
+				c.Align = AlignLeft
+			case "right", "r":
+				c.Align = AlignRight
+			default:
+				return nil, fmt.Errorf("invalid alignment %q for column %s. Use left or right", align, name)
+			}
+		}
+
+		columns = append(columns, c)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given. Use %s", strings.Join(columnNames(), ", "))
+	}
+	return columns, nil
+}
+
+func validateColumns(v string) error {
+	_, err := parseColumns(v)
+	return err
+}
+
+// formatAge renders a duration in its largest whole unit: 45m, 5h, 3d, 2w.
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+}
+
+// newTodoTable lays out todos with the given columns.
+func newTodoTable(todos []Todo, columns []todoColumn) *Table {
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+
+	table := NewTable(headers)
+	table.Wrap = cfg.Get("table.overflow") == "wrap"
+	for i, c := range columns {
+		table.Flexible[i] = c.Flexible
+		table.Align[i] = c.Align
+	}
+
+	values := make([]func(Todo) string, len(columns))
+	for i, c := range columns {
+		values[i] = c.Value
+		if c.Prepare != nil {
+			values[i] = c.Prepare(todos)
+		}
+	}
+
+	for _, todo := range todos {
+		row := make([]string, len(columns))
+		for i, value := range values {
+			row[i] = value(todo)
+		}
+		table.AddRow(row)
+	}
+
+	return table
+}
+
+var groupKeys = []string{"category", "priority", "status"}
+
+// todoGroup is one section of a grouped list.
+type todoGroup struct {
+	Title string
+	Todos []Todo
+}
+
+// groupTodos splits todos into sections by key, keeping their order within
+// each section. Categories sort by name with uncategorized todos last,
+// priorities from high to low and pending todos before done ones.
+func groupTodos(todos []Todo, key string) ([]todoGroup, error) {
+	var title func(todo Todo) string
+	var rank func(title string) int
+
+	switch key {
+	case "category":
+		title = func(todo Todo) string {
+			if todo.Category == "" {
+				return "(none)"
+			}
+			return todo.Category
+		}
+	case "priority":
+		title = func(todo Todo) string { return string(todo.Priority) }
+		rank = func(title string) int { return -Priority(title).Rank() }
+	case "status":
+		title = func(todo Todo) string {
+			if todo.Done {
+				return "done"
+			}
+			return "pending"
+		}
+		rank = func(title string) int {
+			if title == "done" {
+				return 1
+			}
+			return 0
+		}
+	default:
+		return nil, fmt.Errorf("invalid group %q. Use %s", key, strings.Join(groupKeys, ", "))
+	}
+
+	var groups []todoGroup
+	index := map[string]int{}
+	for _, todo := range todos {
+		t := title(todo)
+		i, ok := index[t]
+		if !ok {
+			i = len(groups)
+			index[t] = i
+			groups = append(groups, todoGroup{Title: t})
+		}
+		groups[i].Todos = append(groups[i].Todos, todo)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Title, groups[j].Title
+		if rank != nil {
+			return rank(a) < rank(b)
+		}
+		if (a == "(none)") != (b == "(none)") {
+			return b == "(none)"
+		}
+		return a < b
+	})
+
+	return groups, nil
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,75 +12,395 @@ func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
 }
 
-func cmdAdd(title string, priority Priority, category, dueDate string) error {
-	if title == "" {
+// parseDuration accepts anything time.ParseDuration does plus whole days
+// ("7d") and weeks ("2w"), also ahead of smaller units ("1d2h", as
+// shortDuration writes them).
+func parseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid duration %q. Use e.g. 30m, 12h, 7d, 2w or 1d2h", s)
+
+	// Leading weeks and days, then the rest as a Go duration
+	var total time.Duration
+	rest := s
+	for {
+		digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+		if digits == 0 || digits == len(rest) || (rest[digits] != 'd' && rest[digits] != 'w') {
+			break
+		}
+		count, err := strconv.Atoi(rest[:digits])
+		if err != nil {
+			return 0, invalid
+		}
+		unit := 24 * time.Hour
+		if rest[digits] == 'w' {
+			unit *= 7
+		}
+		total += time.Duration(count) * unit
+		rest = rest[digits+1:]
+	}
+	if s != "" && rest == "" {
+		return total, nil
+	}
+
+	d, err := time.ParseDuration(rest)
+	if err != nil || d < 0 {
+		return 0, invalid
+	}
+	return total + d, nil
+}
+
+// parseSince turns either a YYYY-MM-DD date or a duration ago into a point
+// in time.
+func parseSince(s string) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return date, nil
+	}
+
+	d, err := parseDuration(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return clock().Add(-d), nil
+}
+
+// addOptions are the fields of a new todo as given to add. Empty fields
+// come from quick-add tokens in the title, then from the active context
+// and the configured defaults.
+type addOptions struct {
+	Title    string
+	Priority Priority
+	Category string
+	DueDate  string
+	Estimate string
+
+	// NoParse stores the title as typed, without quick-add tokens.
+	NoParse bool
+}
+
+func cmdAdd(opts addOptions) error {
+	if !opts.NoParse {
+		qa, err := parseQuickAdd(opts.Title, clock())
+		if err != nil {
+			return err
+		}
+
+		// Fields given explicitly win over inline tokens
+		opts.Title = qa.Title
+		if opts.Priority == "" {
+			opts.Priority = qa.Priority
+		}
+		if opts.Category == "" {
+			opts.Category = qa.Category
+		}
+		if opts.DueDate == "" {
+			opts.DueDate = qa.DueDate
+		}
+	}
+
+	defPriority, defCategory := addDefaults()
+	if opts.Priority == "" {
+		opts.Priority = defPriority
+	}
+	if opts.Category == "" {
+		opts.Category = defCategory
+	}
+
+	if opts.Title == "" {
 		return fmt.Errorf("title can not be empty")
 	}
 
-	if !priority.IsValid() {
-		return fmt.Errorf("invalid priority: %s. Use low, medium, or high", priority)
+	if err := currentPriorities().check(opts.Priority); err != nil {
+		return err
 	}
 
-	if dueDate != "" {
-		_, err := parseDate(dueDate)
+	if opts.DueDate != "" {
+		_, err := parseDate(opts.DueDate)
 		if err != nil {
 			return fmt.Errorf("invalid date format. Use YYYY-MM-DD")
 		}
 	}
 
-	id, err := insertTodo(title, priority, category, dueDate)
+	e, err := parseEstimate(opts.Estimate)
+	if err != nil {
+		return err
+	}
+
+	project, err := projectForNewTodo()
+	if err != nil {
+		return err
+	}
+
+	id, err := insertTodoWith(opts.Title, opts.Priority, opts.Category, opts.DueDate, e, project)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("%s Added todo #%d: %s\n", colorize(Green, "✓"), id, title)
+	fmt.Printf("%s Added todo #%d%s: %s\n", colorize(Green, "✓"), id, projectHeader(), opts.Title)
+	return nil
+}
+
+// listOptions collects the filters and display settings for list.
+type listOptions struct {
+	ShowAll  bool
+	ShowDone bool
+	Priority Priority
+	Category string
+	Sort     string
+	Columns  string
+	GroupBy  string
+	Style    string
+
+	// CompletedSince limits the list to todos completed since a duration
+	// ago or a date. It implies ShowDone.
+	CompletedSince string
+
+	// Snoozed lists only the snoozed todos instead of hiding them.
+	Snoozed bool
+}
+
+// listFilter decides which todos list shows from the --all, --done and
+// --pending flags, falling back to the list.filter setting when none of
+// them is given.
+func listFilter(all, done, pending bool, configured string) (showAll, showDone bool, err error) {
+	set := 0
+	for _, f := range []bool{all, done, pending} {
+		if f {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return false, false, fmt.Errorf("--all, --done and --pending can not be combined")
+	case set == 0:
+		return configured == "all", configured == "done", nil
+	}
+	return all, done, nil
+}
+
+var sortKeys = []string{"id", "due", "priority", "urgency", "created", "completed", "title"}
+
+// sortTodos orders todos in place by one of sortKeys. Todos without a due
+// date or completion time sort after those with one, and the most urgent
+// todos come first; ties keep ID order.
+func sortTodos(todos []Todo, by string) error {
+	var less func(a, b Todo) bool
+
+	switch by {
+	case "", "id":
+		less = func(a, b Todo) bool { return a.ID < b.ID }
+	case "due":
+		less = func(a, b Todo) bool {
+			if a.DueDate.Valid != b.DueDate.Valid {
+				return a.DueDate.Valid
+			}
+			return a.DueDate.Time.Before(b.DueDate.Time)
+		}
+	case "priority":
+		less = func(a, b Todo) bool { return a.Priority.Rank() > b.Priority.Rank() }
+	case "urgency":
+		scores := urgencyScores(todos)
+		less = func(a, b Todo) bool { return scores[a.ID] > scores[b.ID] }
+	case "created":
+		less = func(a, b Todo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "completed":
+		less = func(a, b Todo) bool {
+			if a.CompletedAt.Valid != b.CompletedAt.Valid {
+				return a.CompletedAt.Valid
+			}
+			return a.CompletedAt.Time.Before(b.CompletedAt.Time)
+		}
+	case "title":
+		less = func(a, b Todo) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	default:
+		return fmt.Errorf("invalid sort %q. Use %s", by, strings.Join(sortKeys, ", "))
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool { return less(todos[i], todos[j]) })
 	return nil
 }
 
-func cmdList(showAll, showDone bool, priority Priority, category string) error {
-	todos, err := getAllTodos(showAll, showDone, priority, category)
+func cmdList(opts listOptions) error {
+	var completedSince time.Time
+	if opts.CompletedSince != "" {
+		var err error
+		completedSince, err = parseSince(opts.CompletedSince)
+		if err != nil {
+			return err
+		}
+		opts.ShowAll, opts.ShowDone = false, true
+	}
+
+	if opts.Snoozed {
+		opts.ShowAll, opts.ShowDone = false, false
+	}
+
+	// Filters given explicitly win over the context
+	if activeContext != nil {
+		if opts.Category == "" {
+			opts.Category = activeContext.Category
+		}
+		if opts.Priority == "" {
+			opts.Priority = activeContext.Priority
+		}
+	}
+
+	todos, err := getTodos(todoFilter{
+		ShowAll:  opts.ShowAll,
+		ShowDone: opts.ShowDone,
+		Snoozed:  true,
+		Priority: opts.Priority,
+		Category: opts.Category,
+		Project:  activeProjectID(),
+	})
 	if err != nil {
 		return err
 	}
 
-	if showDone {
-		fmt.Println("\nCompleted Todos:")
-	} else if showAll {
-		fmt.Println("\nAll Todos:")
-	} else {
-		fmt.Println("\nPending Todos:")
+	// Snoozed todos are fetched too so the list can say how many are hidden
+	now := clock()
+	var awake, snoozed []Todo
+	for _, todo := range todos {
+		if isSnoozed(todo, now) {
+			snoozed = append(snoozed, todo)
+		} else {
+			awake = append(awake, todo)
+		}
+	}
+	todos = awake
+	if opts.Snoozed {
+		todos = snoozed
 	}
-	fmt.Println("---------------------------------------")
 
-	table := NewTable([]string{"ID", "✓", "Title", "Priority", "Category", "Due"})
+	if !completedSince.IsZero() {
+		todos = completedAfter(todos, completedSince)
+	}
 
-	for _, todo := range todos {
-		statusDisplay := " "
-		if todo.Done {
-			statusDisplay = colorize(Green, "✓")
+	if err := sortTodos(todos, opts.Sort); err != nil {
+		return err
+	}
+
+	spec := opts.Columns
+	if spec == "" {
+		spec = defaultColumns
+	}
+	if opts.Snoozed && !strings.Contains(","+spec+",", ",snoozed") {
+		spec += ",snoozed"
+	}
+	columns, err := parseColumns(spec)
+	if err != nil {
+		return err
+	}
+
+	var groups []todoGroup
+	if opts.GroupBy != "" {
+		groups, err = groupTodos(todos, opts.GroupBy)
+		if err != nil {
+			return err
 		}
-		priorityDisplay := colorize(priorityColor(todo.Priority), string(todo.Priority))
-		dueDateDisplay := formatDueDate(todo.DueDate)
+	}
 
-		table.AddRow([]string{
-			fmt.Sprintf("%d", todo.ID),
-			statusDisplay,
-			todo.Title,
-			priorityDisplay,
-			todo.Category,
-			dueDateDisplay,
-		})
+	var style TableStyle
+	if opts.Style != "" {
+		var ok bool
+		style, ok = tableStyles[opts.Style]
+		if !ok {
+			return fmt.Errorf("invalid style %q. Use %s", opts.Style, strings.Join(tableStyleNames(), ", "))
+		}
 	}
 
-	if len(table.Rows) == 0 {
+	heading := "Pending Todos"
+	if opts.Snoozed {
+		heading = "Snoozed Todos"
+	} else if opts.ShowDone {
+		heading = "Completed Todos"
+	} else if opts.ShowAll {
+		heading = "All Todos"
+	}
+	fmt.Printf("\n%s%s%s:\n", heading, projectHeader(), contextHeader())
+	fmt.Println("---------------------------------------")
+
+	if len(todos) == 0 {
 		fmt.Println("No todos found")
-	} else {
-		table.Print()
+		printSnoozedHint(opts, snoozed)
+		return nil
 	}
 
+	if groups == nil {
+		groups = []todoGroup{{Todos: todos}}
+	}
+
+	tables := make([]*Table, len(groups))
+	for i, g := range groups {
+		tables[i] = newTodoTable(g.Todos, columns)
+		if opts.Style != "" {
+			tables[i].Style = style
+		}
+	}
+	alignWidths(tables)
+
+	for i, g := range groups {
+		if g.Title != "" {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s (%d)\n", colorize(Bold, g.Title), len(g.Todos))
+		}
+		tables[i].Print()
+	}
+
+	printEstimateTotals(todos)
+	printOffScaleHint(todos)
+	printSnoozedHint(opts, snoozed)
 	return nil
 }
 
+// printSnoozedHint mentions the snoozed todos a pending list left out.
+func printSnoozedHint(opts listOptions, snoozed []Todo) {
+	if !opts.Snoozed && len(snoozed) > 0 {
+		fmt.Printf("%d snoozed todo(s) hidden. Use list --snoozed to see them\n", len(snoozed))
+	}
+}
+
+// printOffScaleHint points out todos left behind by a change of
+// priority.levels.
+func printOffScaleHint(todos []Todo) {
+	s := currentPriorities()
+	off := 0
+	for _, todo := range todos {
+		if s.index(todo.Priority) < 0 {
+			off++
+		}
+	}
+	if off > 0 {
+		fmt.Printf("%d todo(s) have a priority not in priority.levels. Use priorities migrate to fix them\n", off)
+	}
+}
+
+// completedAfter keeps the todos completed at or after since.
+func completedAfter(todos []Todo, since time.Time) []Todo {
+	var kept []Todo
+	for _, todo := range todos {
+		if todo.CompletedAt.Valid && !todo.CompletedAt.Time.Before(since) {
+			kept = append(kept, todo)
+		}
+	}
+	return kept
+}
+
+// todoTable lays out todos with the default list columns.
+func todoTable(todos []Todo) *Table {
+	columns, _ := parseColumns(defaultColumns)
+	return newTodoTable(todos, columns)
+}
+
+// confirm asks a yes/no question, defaulting to no.
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	var response string
+	fmt.Scanln(&response)
+
+	return response == "y" || response == "Y"
+}
+
 func cmdDone(id int) error {
 	err := markTodoAsDone(id)
 	if err != nil {
@@ -104,15 +427,9 @@ func cmdDelete(id int, force bool) error {
 		return err
 	}
 
-	if !force {
-		fmt.Printf("Delete todo #%d: \"%s\"? [y/N] ", todo.ID, todo.Title)
-		var response string
-		fmt.Scanln(&response)
-
-		if response != "y" && response != "Y" {
-			fmt.Println("Cancelled")
-			return nil
-		}
+	if !force && !confirm(fmt.Sprintf("Delete todo #%d: \"%s\"?", todo.ID, todo.Title)) {
+		fmt.Println("Cancelled")
+		return nil
 	}
 
 	err = deleteTodo(id)
@@ -129,6 +446,9 @@ func cmdShow(id int) error {
 	if err != nil {
 		return err
 	}
+	if !activeContext.matches(*todo) {
+		return fmt.Errorf("todo #%d is outside context %s. Use --context= to show it anyway", id, activeContext.Name)
+	}
 
 	fmt.Println()
 	fmt.Println("──────────────────────────────────────")
@@ -137,9 +457,14 @@ func cmdShow(id int) error {
 
 	// Show status
 	if todo.Done {
-		fmt.Printf("  Status:    %s\n", colorize(Green, "Done"))
+		fmt.Printf("  Status:    %s\n", colorize(roleColor("done"), "Done"))
 	} else {
-		fmt.Printf("  Status:    %s\n", colorize(Yellow, "Pending"))
+		fmt.Printf("  Status:    %s\n", colorize(roleColor("pending"), "Pending"))
+	}
+
+	// Only show the workflow state once the todo has been moved
+	if todo.State != "" {
+		fmt.Printf("  State:     %s\n", currentWorkflow().stateOf(*todo))
 	}
 
 	fmt.Printf("  Priority:  %s\n", colorize(priorityColor(todo.Priority), string(todo.Priority)))
@@ -149,14 +474,47 @@ func cmdShow(id int) error {
 		fmt.Printf("  Category:  %s\n", todo.Category)
 	}
 
-	fmt.Printf("  Created:   %s\n", todo.CreatedAt.Format("2006-01-02 15:04"))
+	if todo.ProjectID != 0 {
+		fmt.Printf("  Project:   %s\n", projectName(todo.ProjectID))
+	}
+
+	fmt.Printf("  Created:   %s\n", todo.CreatedAt.Format(cfg.Get("date.format")+" 15:04"))
 
 	// Only show due date if set
 	if todo.DueDate.Valid {
 		fmt.Printf("  Due:       %s\n", formatDueDate(todo.DueDate))
 	}
 
+	if todo.CompletedAt.Valid {
+		fmt.Printf("  Completed: %s\n", todo.CompletedAt.Time.Local().Format(cfg.Get("date.format")+" 15:04"))
+	}
+
+	if isSnoozed(*todo, clock()) {
+		fmt.Printf("  Snoozed:   until %s\n", formatSnooze(todo.WaitUntil.Time))
+	}
+
+	if e := estimateOf(*todo); !e.IsZero() {
+		fmt.Printf("  Estimate:  %s\n", e.Display())
+	}
+
+	if err := printTimeSpent(todo.ID); err != nil {
+		return err
+	}
+
+	if err := printPomodoros(todo.ID); err != nil {
+		return err
+	}
+
 	fmt.Println("──────────────────────────────────────")
+
+	// Notes go below the metadata since they can span many lines
+	if strings.TrimSpace(todo.Notes) != "" {
+		for _, line := range renderMarkdown(todo.Notes) {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println("──────────────────────────────────────")
+	}
+
 	fmt.Println()
 	return nil
 }
@@ -174,8 +532,10 @@ func cmdEdit(id int, title string, priority Priority, category, dueDate string)
 		}
 	}
 
-	if priority != "" && !priority.IsValid() {
-		return fmt.Errorf("invalid priority: %s. Use low, medium, or high", priority)
+	if priority != "" {
+		if err := currentPriorities().check(priority); err != nil {
+			return err
+		}
 	}
 
 	if title == "" && priority == "" && category == "" && dueDate == "" {
@@ -191,45 +551,172 @@ func cmdEdit(id int, title string, priority Priority, category, dueDate string)
 	return nil
 }
 
-func cmdClear(clearAll bool) error {
+func cmdEditInteractive(id int) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+
+	updated, err := editTodoDocument(todo)
+	if err != nil {
+		return err
+	}
+
+	diff := todoDiff(todo, updated)
+	if len(diff) == 0 {
+		fmt.Printf("No changes to todo #%d\n", id)
+		return nil
+	}
+
+	for _, line := range diff {
+		fmt.Println(line)
+	}
+
+	err = saveTodo(updated)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated todo #%d\n", id)
+	return nil
+}
+
+// todoDiff describes the fields that differ between before and after, one
+// removed/added line per value line.
+func todoDiff(before, after *Todo) []string {
+	var lines []string
+
+	oldFields := todoFields(before)
+	newFields := todoFields(after)
+	for i, f := range oldFields {
+		if f.Value == newFields[i].Value || f.Derived {
+			continue
+		}
+
+		lines = append(lines, "  "+f.Label+":")
+		if f.Value != "" {
+			for _, l := range strings.Split(f.Value, "\n") {
+				lines = append(lines, colorize(Red, "    - "+l))
+			}
+		}
+		if newFields[i].Value != "" {
+			for _, l := range strings.Split(newFields[i].Value, "\n") {
+				lines = append(lines, colorize(Green, "    + "+l))
+			}
+		}
+	}
+
+	return lines
+}
+
+func cmdNote(id int, appendText string) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+
+	var notes string
+	if appendText != "" {
+		notes = todo.Notes
+		if notes != "" && !strings.HasSuffix(notes, "\n") {
+			notes += "\n"
+		}
+		notes += appendText
+	} else {
+		notes, err = editText(fmt.Sprintf("todo-%d-*.md", id), todo.Notes)
+		if err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+	}
+
+	notes = strings.TrimRight(notes, "\n")
+	if notes == strings.TrimRight(todo.Notes, "\n") {
+		fmt.Printf("No changes to notes of todo #%d\n", id)
+		return nil
+	}
+
+	err = setTodoNotes(id, notes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated notes of todo #%d\n", id)
+	return nil
+}
+
+// cmdClear removes completed todos, or every todo with clearAll. A non-empty
+// olderThan only removes todos completed more than that long ago.
+func cmdClear(clearAll bool, olderThan string, force bool) error {
 	var count int
 	var err error
 
-	if clearAll {
+	var before time.Time
+	if olderThan != "" {
+		if clearAll {
+			return fmt.Errorf("--older-than can not be combined with --all")
+		}
+		d, err := parseDuration(olderThan)
+		if err != nil {
+			return err
+		}
+		before = clock().Add(-d)
+	}
+
+	switch {
+	case clearAll:
 		count, err = countAllTodos()
-	} else {
+	case olderThan != "":
+		count, err = countCompletedBefore(before)
+	default:
 		count, err = countCompletedTodos()
 	}
 	if err != nil {
 		return err
 	}
 
+	// Only the todos in the active project and context are cleared
+	var scopes []string
+	if activeProject != nil {
+		scopes = append(scopes, "project "+activeProject.Name)
+	}
+	if activeContext != nil {
+		scopes = append(scopes, "context "+activeContext.Name)
+	}
+	scope := ""
+	if len(scopes) > 0 {
+		scope = " in " + strings.Join(scopes, " and ")
+	}
+
 	if count == 0 {
-		if clearAll {
-			fmt.Println("No todos to clear")
-		} else {
-			fmt.Println("No completed todos to clear")
+		switch {
+		case clearAll:
+			fmt.Printf("No todos to clear%s\n", scope)
+		case olderThan != "":
+			fmt.Printf("No todos completed more than %s ago%s\n", olderThan, scope)
+		default:
+			fmt.Printf("No completed todos to clear%s\n", scope)
 		}
 		return nil
 	}
 
+	prompt := fmt.Sprintf("Delete %d completed todos%s?", count, scope)
 	if clearAll {
-		fmt.Printf("Delete ALL %d todos? This cannot be undone. [y/N] ", count)
-	} else {
-		fmt.Printf("Delete %d completed todos? [y/N] ", count)
+		prompt = fmt.Sprintf("Delete ALL %d todos%s?", count, scope)
+	} else if olderThan != "" {
+		prompt = fmt.Sprintf("Delete %d todos completed more than %s ago%s?", count, olderThan, scope)
 	}
 
-	var response string
-	fmt.Scanln(&response)
-
-	if response != "y" && response != "Y" {
+	if !force && !confirm(prompt) {
 		fmt.Println("Cancelled")
 		return nil
 	}
 
-	if clearAll {
+	switch {
+	case clearAll:
 		err = clearAllTodos()
-	} else {
+	case olderThan != "":
+		err = clearCompletedBefore(before)
+	default:
 		err = clearCompletedTodos()
 	}
 	if err != nil {
@@ -237,10 +724,200 @@ func cmdClear(clearAll bool) error {
 	}
 
 	if clearAll {
-		fmt.Printf("Cleared all %d todos\n", count)
+		fmt.Printf("Cleared all %d todos%s\n", count, scope)
 	} else {
-		fmt.Printf("Cleared %d completed todos\n", count)
+		fmt.Printf("Cleared %d completed todos%s\n", count, scope)
 	}
 
 	return nil
 }
+
+func cmdUndo(steps int) error {
+	return printJournalSteps(steps, undoJournal, "Undid", "Nothing to undo")
+}
+
+func cmdRedo(steps int) error {
+	return printJournalSteps(steps, redoJournal, "Redid", "Nothing to redo")
+}
+
+func printJournalSteps(steps int, replay func(int) ([]journalStep, error), verb, empty string) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	result, err := replay(steps)
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		fmt.Println(empty)
+		return nil
+	}
+
+	for _, step := range result {
+		fmt.Printf("%s %s '%s'\n", colorize(Blue, "↺"), verb, step.Command)
+		for _, msg := range step.Messages {
+			fmt.Printf("  %s\n", msg)
+		}
+	}
+
+	return nil
+}
+
+func cmdHistory(id int) error {
+	entries, err := getTodoHistory(id)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No history for todo #%d\n", id)
+		return nil
+	}
+
+	fmt.Printf("\nHistory of todo #%d:\n", id)
+	fmt.Println("---------------------------------------")
+
+	table := NewTable([]string{"When", "Actor", "Change"})
+	for _, h := range entries {
+		table.AddRow([]string{
+			h.ChangedAt.Local().Format("2006-01-02 15:04"),
+			h.Actor,
+			describeChange(h),
+		})
+	}
+	table.Print()
+
+	return nil
+}
+
+func cmdLog(since string) error {
+	from, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+
+	entries, err := getHistorySince(from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nActivity since %s:\n", from.Format("2006-01-02 15:04"))
+	fmt.Println("---------------------------------------")
+
+	table := NewTable([]string{"When", "ID", "Actor", "Change"})
+	for _, h := range entries {
+		table.AddRow([]string{
+			h.ChangedAt.Local().Format("2006-01-02 15:04"),
+			fmt.Sprintf("%d", h.TodoID),
+			h.Actor,
+			describeChange(h),
+		})
+	}
+
+	if len(table.Rows) == 0 {
+		fmt.Println("No activity found")
+	} else {
+		table.Print()
+	}
+
+	return nil
+}
+
+func describeChange(h HistoryEntry) string {
+	switch h.Action {
+	case actionCreated:
+		return colorize(Green, "created") + " '" + h.NewValue + "'"
+	case actionDeleted:
+		return colorize(Red, "deleted") + " '" + h.OldValue + "'"
+	}
+
+	label, ok := fieldLabel(h.Field)
+	if !ok {
+		label = h.Field
+	}
+	return fmt.Sprintf("%s: %s → %s", label, historyValue(h.Field, h.OldValue), historyValue(h.Field, h.NewValue))
+}
+
+func historyValue(field, value string) string {
+	switch {
+	case field == "done" && value == "1":
+		return "done"
+	case field == "done":
+		return "pending"
+	case value == "":
+		return "(none)"
+	case field == "notes":
+		return "'" + summarizeNotes(value) + "'"
+	case field == "completed_at" || field == "wait_until":
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.Local().Format(cfg.Get("date.format") + " 15:04")
+		}
+	case field == "estimate":
+		if e, err := parseEstimate(value); err == nil {
+			return e.Display()
+		}
+	case field == "project_id":
+		if id, err := strconv.Atoi(value); err == nil {
+			if id == 0 {
+				return "(none)"
+			}
+			return projectName(id)
+		}
+	}
+	return "'" + value + "'"
+}
+
+// summarizeNotes shortens notes to their first line for one-line displays.
+func summarizeNotes(notes string) string {
+	first, rest, _ := strings.Cut(strings.TrimSpace(notes), "\n")
+	runes := []rune(first)
+	if len(runes) > 40 {
+		return string(runes[:40]) + "…"
+	}
+	if rest != "" {
+		return first + " …"
+	}
+	return first
+}
+
+func cmdConfigGet(name string) error {
+	if _, ok := lookupConfigKey(name); !ok {
+		return fmt.Errorf("unknown setting %q", name)
+	}
+
+	fmt.Println(cfg.Get(name))
+	return nil
+}
+
+func cmdConfigSet(name, value string) error {
+	err := cfg.Set(name, value)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Set %s = %q in %s\n", name, value, cfg.Path)
+	switch _, source := cfg.Lookup(name); source {
+	case "env":
+		fmt.Printf("Note: %s is set in the environment and takes precedence\n", configEnvVar(name))
+	case "project":
+		fmt.Printf("Note: project %s sets %s and takes precedence\n", activeProject.Name, name)
+	}
+	return nil
+}
+
+func cmdConfigList() error {
+	table := NewTable([]string{"Setting", "Value", "Source", "Description"})
+	for _, name := range configNames() {
+		k, _ := lookupConfigKey(name)
+		value, source := cfg.Lookup(name)
+		table.AddRow([]string{name, value, source, k.Help})
+	}
+
+	if cfg.Path != "" {
+		fmt.Printf("\nConfig file: %s\n", cfg.Path)
+	}
+	table.Print()
+	return nil
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"database/sql"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseDate(t *testing.T) {
@@ -63,6 +66,41 @@ func TestParseDate(t *testing.T) {
 	}
 }
 
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "days", input: "7d", want: 7 * 24 * time.Hour},
+		{name: "weeks", input: "2w", want: 14 * 24 * time.Hour},
+		{name: "hours", input: "12h", want: 12 * time.Hour},
+		{name: "hours and minutes", input: "1h30m", want: 90 * time.Minute},
+		{name: "zero days", input: "0d", want: 0},
+		{name: "days and hours", input: "1d2h", want: 26 * time.Hour},
+		{name: "weeks, days and minutes", input: "1w3d30m", want: 10*24*time.Hour + 30*time.Minute},
+		{name: "days then text", input: "1dsoon", wantErr: true},
+		{name: "fractional days", input: "1.5d", wantErr: true},
+		{name: "negative", input: "-3d", wantErr: true},
+		{name: "missing number", input: "d", wantErr: true},
+		{name: "random text", input: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDuration(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCmdAdd_Success(t *testing.T) {
 	setupTestDB(t)
 	defer teardownTestDB()
@@ -99,7 +137,7 @@ func TestCmdAdd_Success(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := cmdAdd(tt.title, tt.priority, tt.category, tt.dueDate)
+			err := cmdAdd(addOptions{Title: tt.title, Priority: tt.priority, Category: tt.category, DueDate: tt.dueDate})
 			if err != nil {
 				t.Errorf("cmdAdd() unexpected error = %v", err)
 				return
@@ -173,7 +211,7 @@ func TestCmdAdd_Validation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := cmdAdd(tt.title, tt.priority, tt.category, tt.dueDate)
+			err := cmdAdd(addOptions{Title: tt.title, Priority: tt.priority, Category: tt.category, DueDate: tt.dueDate})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("cmdAdd() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -582,7 +620,7 @@ func TestCmdList(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := cmdList(tt.showAll, tt.showDone, tt.priority, tt.category)
+			err := cmdList(listOptions{ShowAll: tt.showAll, ShowDone: tt.showDone, Priority: tt.priority, Category: tt.category})
 			if err != nil {
 				t.Errorf("cmdList() unexpected error = %v", err)
 			}
@@ -594,7 +632,7 @@ func TestCmdList(t *testing.T) {
 		if err := clearAllTodos(); err != nil {
 			t.Fatalf("failed to clear todos: %v", err)
 		}
-		err := cmdList(false, false, "", "")
+		err := cmdList(listOptions{})
 		if err != nil {
 			t.Errorf("cmdList() with empty db error = %v", err)
 		}
@@ -604,6 +642,179 @@ func TestCmdList(t *testing.T) {
 	_ = id1
 }
 
+func TestListFilter(t *testing.T) {
+	tests := []struct {
+		name               string
+		all, done, pending bool
+		configured         string
+		wantAll, wantDone  bool
+		wantErr            bool
+	}{
+		{name: "no flags, pending config", configured: "pending"},
+		{name: "no flags, all config", configured: "all", wantAll: true},
+		{name: "no flags, done config", configured: "done", wantDone: true},
+		{name: "--all beats done config", all: true, configured: "done", wantAll: true},
+		{name: "--done beats all config", done: true, configured: "all", wantDone: true},
+		{name: "--pending beats all config", pending: true, configured: "all"},
+		{name: "--pending beats done config", pending: true, configured: "done"},
+		{name: "--all with --done", all: true, done: true, wantErr: true},
+		{name: "--done with --pending", done: true, pending: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		all, done, err := listFilter(tt.all, tt.done, tt.pending, tt.configured)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: listFilter() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if all != tt.wantAll || done != tt.wantDone {
+			t.Errorf("%s: listFilter() = %v, %v, want %v, %v", tt.name, all, done, tt.wantAll, tt.wantDone)
+		}
+	}
+}
+
+func TestCmdListLayout(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Write report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Buy milk", PriorityLow, "home", "")
+	insertTestTodo(t, "Stretch", PriorityMedium, "", "")
+
+	t.Run("columns and style", func(t *testing.T) {
+		out := captureOutput(func() {
+			err := cmdList(listOptions{Columns: "id,title,age", Style: "markdown"})
+			if err != nil {
+				t.Errorf("cmdList() unexpected error = %v", err)
+			}
+		})
+		if !strings.Contains(out, "| ID | Title        | Age |") {
+			t.Errorf("cmdList() header missing, got:\n%s", out)
+		}
+		if strings.Contains(out, "Priority") {
+			t.Errorf("cmdList() shows unselected column:\n%s", out)
+		}
+	})
+
+	t.Run("group by category", func(t *testing.T) {
+		out := captureOutput(func() {
+			err := cmdList(listOptions{GroupBy: "category", Style: "compact"})
+			if err != nil {
+				t.Errorf("cmdList() unexpected error = %v", err)
+			}
+		})
+		out = stripAnsi(out)
+		home := strings.Index(out, "home (1)")
+		work := strings.Index(out, "work (1)")
+		none := strings.Index(out, "(none) (1)")
+		if home < 0 || work < 0 || none < 0 || !(home < work && work < none) {
+			t.Errorf("cmdList() sections out of order:\n%s", out)
+		}
+	})
+
+	for _, tt := range []struct {
+		name string
+		opts listOptions
+		want string
+	}{
+		{"unknown column", listOptions{Columns: "id,colour"}, "unknown column"},
+		{"bad alignment", listOptions{Columns: "id:middle"}, "invalid alignment"},
+		{"unknown group", listOptions{GroupBy: "due"}, "invalid group"},
+		{"unknown style", listOptions{Style: "fancy"}, "invalid style"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cmdList(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("cmdList() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCmdListCompletedSince(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Finished today", PriorityMedium, "", "")
+	insertTestTodo(t, "Finished last month", PriorityMedium, "", "")
+	insertTestTodo(t, "Still open", PriorityMedium, "", "")
+	markTodoAsDone(1)
+	markTodoAsDone(2)
+	db.Exec(`UPDATE todos SET completed_at = ? WHERE id = 2`, time.Now().AddDate(0, -1, 0).UTC())
+
+	out := captureOutput(func() {
+		if err := cmdList(listOptions{CompletedSince: "7d", Sort: "completed"}); err != nil {
+			t.Errorf("cmdList() unexpected error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "Finished today") {
+		t.Errorf("cmdList() missing recently completed todo:\n%s", out)
+	}
+	if strings.Contains(out, "Finished last month") || strings.Contains(out, "Still open") {
+		t.Errorf("cmdList() shows todos outside the window:\n%s", out)
+	}
+
+	if err := cmdList(listOptions{CompletedSince: "whenever"}); err == nil {
+		t.Errorf("cmdList() with invalid --completed-since should fail")
+	}
+}
+
+func TestCmdClearOlderThan(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Old", PriorityMedium, "", "")
+	insertTestTodo(t, "Recent", PriorityMedium, "", "")
+	markTodoAsDone(1)
+	markTodoAsDone(2)
+	db.Exec(`UPDATE todos SET completed_at = ? WHERE id = 1`, time.Now().AddDate(0, 0, -45).UTC())
+
+	if err := cmdClear(false, "30d", true); err != nil {
+		t.Fatalf("cmdClear() error = %v", err)
+	}
+
+	count, _ := countAllTodos()
+	if count != 1 {
+		t.Errorf("after cmdClear(--older-than 30d) count = %d, want 1", count)
+	}
+	if _, err := getTodoByID(2); err != nil {
+		t.Errorf("recently completed todo should be kept: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		all       bool
+		olderThan string
+		want      string
+	}{
+		{"bad duration", false, "a while", "invalid duration"},
+		{"combined with all", true, "30d", "can not be combined"},
+	} {
+		err := cmdClear(tt.all, tt.olderThan, true)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: cmdClear() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Minute, "45m"},
+		{5 * time.Hour, "5h"},
+		{3 * 24 * time.Hour, "3d"},
+		{21 * 24 * time.Hour, "3w"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
 func TestCmdDelete(t *testing.T) {
 	setupTestDB(t)
 	defer teardownTestDB()
@@ -655,3 +866,183 @@ func TestCmdDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestCmdUndoRedo(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "", ""))
+	if err := cmdDone(id); err != nil {
+		t.Fatalf("cmdDone() error = %v", err)
+	}
+
+	if err := cmdUndo(1); err != nil {
+		t.Fatalf("cmdUndo() error = %v", err)
+	}
+	todo, _ := getTodoByID(id)
+	if todo.Done {
+		t.Errorf("todo should be pending after undo")
+	}
+
+	if err := cmdRedo(1); err != nil {
+		t.Fatalf("cmdRedo() error = %v", err)
+	}
+	todo, _ = getTodoByID(id)
+	if !todo.Done {
+		t.Errorf("todo should be done after redo")
+	}
+
+	if err := cmdUndo(0); err == nil || !strings.Contains(err.Error(), "at least 1") {
+		t.Errorf("cmdUndo(0) error = %v, want steps validation error", err)
+	}
+}
+
+func TestCmdHistoryAndLog(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "", ""))
+	updateTodo(id, "Renamed", "", "", "")
+
+	if err := cmdHistory(id); err != nil {
+		t.Errorf("cmdHistory() error = %v", err)
+	}
+	if err := cmdHistory(999); err != nil {
+		t.Errorf("cmdHistory() for unknown todo error = %v", err)
+	}
+	if err := cmdLog("7d"); err != nil {
+		t.Errorf("cmdLog() error = %v", err)
+	}
+	if err := cmdLog("2024-01-01"); err != nil {
+		t.Errorf("cmdLog() with date error = %v", err)
+	}
+	if err := cmdLog("lately"); err == nil || !strings.Contains(err.Error(), "invalid duration") {
+		t.Errorf("cmdLog() error = %v, want invalid duration", err)
+	}
+}
+
+func TestCmdNote(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "", ""))
+
+	t.Run("append to empty notes", func(t *testing.T) {
+		if err := cmdNote(id, "first line"); err != nil {
+			t.Fatalf("cmdNote() error = %v", err)
+		}
+		todo, _ := getTodoByID(id)
+		if todo.Notes != "first line" {
+			t.Errorf("Notes = %q, want %q", todo.Notes, "first line")
+		}
+	})
+
+	t.Run("append adds a new line", func(t *testing.T) {
+		if err := cmdNote(id, "- second"); err != nil {
+			t.Fatalf("cmdNote() error = %v", err)
+		}
+		todo, _ := getTodoByID(id)
+		if todo.Notes != "first line\n- second" {
+			t.Errorf("Notes = %q, want %q", todo.Notes, "first line\n- second")
+		}
+	})
+
+	t.Run("edit in editor", func(t *testing.T) {
+		orig := runEditor
+		defer func() { runEditor = orig }()
+		runEditor = func(path string) error {
+			return os.WriteFile(path, []byte("# Rewritten\n\nwith `code`\n"), 0o600)
+		}
+
+		if err := cmdNote(id, ""); err != nil {
+			t.Fatalf("cmdNote() error = %v", err)
+		}
+		todo, _ := getTodoByID(id)
+		if todo.Notes != "# Rewritten\n\nwith `code`" {
+			t.Errorf("Notes = %q, want editor contents", todo.Notes)
+		}
+		if err := cmdShow(id); err != nil {
+			t.Errorf("cmdShow() with notes error = %v", err)
+		}
+	})
+
+	t.Run("non-existent todo", func(t *testing.T) {
+		if err := cmdNote(999, "text"); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("cmdNote() error = %v, want not found", err)
+		}
+	})
+}
+
+func TestCmdEditInteractive(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "work", "2026-01-01"))
+
+	orig := runEditor
+	defer func() { runEditor = orig }()
+	runEditor = func(path string) error {
+		return os.WriteFile(path, []byte("---\ntitle: Renamed\npriority: high\ncategory:\ndue:\n---\nnotes\n"), 0o600)
+	}
+
+	if err := cmdEditInteractive(id); err != nil {
+		t.Fatalf("cmdEditInteractive() error = %v", err)
+	}
+
+	todo, _ := getTodoByID(id)
+	if todo.Title != "Renamed" || todo.Priority != PriorityHigh || todo.Category != "" || todo.DueDate.Valid || todo.Notes != "notes" {
+		t.Errorf("todo after edit = %+v", todo)
+	}
+
+	// All fields were changed in a single journal batch
+	steps, err := undoJournal(1)
+	if err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if len(steps) != 1 || len(steps[0].Messages) != 5 {
+		t.Errorf("undoJournal() = %+v, want one step reverting 5 fields", steps)
+	}
+}
+
+func TestSortTodos(t *testing.T) {
+	day := func(d int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	todos := []Todo{
+		{ID: 1, Title: "beta", Priority: PriorityLow, DueDate: day(5)},
+		{ID: 2, Title: "Alpha", Priority: PriorityHigh},
+		{ID: 3, Title: "gamma", Priority: PriorityMedium, DueDate: day(2)},
+	}
+
+	tests := []struct {
+		by      string
+		want    []int
+		wantErr bool
+	}{
+		{by: "id", want: []int{1, 2, 3}},
+		{by: "due", want: []int{3, 1, 2}},
+		{by: "priority", want: []int{2, 3, 1}},
+		{by: "title", want: []int{2, 1, 3}},
+		{by: "size", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			sorted := append([]Todo(nil), todos...)
+			err := sortTodos(sorted, tt.by)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sortTodos(%q) error = %v, wantErr %v", tt.by, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for i, id := range tt.want {
+				if sorted[i].ID != id {
+					t.Errorf("sortTodos(%q) order = %v, want %v", tt.by, sorted, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configKey describes one setting. Settings are resolved from, in order of
+// precedence: command-line flags, the environment, the active project, the
+// config file and the built-in default.
+type configKey struct {
+	Name     string
+	Default  string
+	Help     string
+	Validate func(string) error
+}
+
+var configKeys = append([]configKey{
+	{"defaults.priority", "", "Priority for new todos, the middle priority level if empty", validatePriorityName},
+	{"defaults.category", "", "Category for new todos", nil},
+	{"defaults.project", "", "Project commands work in when --project is not given", validateProjectName},
+	{"context.active", "", "Context that scopes list, show, clear and add; set by context use", validateContextName},
+	{"priority.levels", defaultPriorityLevels, "Priority levels, most important first, e.g. P0,P1,P2,P3", validatePriorityLevels},
+	{"priority.colors", "", "Colors per priority level, e.g. P0:bold red,P1:208", validatePriorityColors},
+	{"list.filter", "pending", "Todos shown by list: pending, all or done", validateOneOf("pending", "all", "done")},
+	{"list.sort", "id", "Sort order for list: " + strings.Join(sortKeys, ", "), validateSortKey},
+	{"list.columns", defaultColumns, "Columns shown by list, e.g. id,title,due,created,age", validateColumns},
+	{"list.group_by", "", "Group list into sections by: " + strings.Join(groupKeys, ", "), validateGroupKey},
+	{"table.style", "unicode", "Table style: " + strings.Join(tableStyleNames(), ", "), validateOneOf(tableStyleNames()...)},
+	{"table.overflow", "wrap", "How long titles fit the terminal: wrap or truncate", validateOneOf("wrap", "truncate")},
+	{"date.format", "2006-01-02", "Date display format, as a Go time layout", validateNonEmpty},
+	{"confirm.delete", "true", "Ask before deleting a todo", validateBool},
+	{"confirm.clear", "true", "Ask before clearing todos", validateBool},
+	{"color.mode", "auto", "When to use color: auto, always or never", validateOneOf("auto", "always", "never")},
+	{"color.theme", "default", "Color theme: " + strings.Join(themeNames(), ", "), validateOneOf(themeNames()...)},
+	{"workflow.states", "backlog,in-progress,review,done", "Workflow states for move and board, from first to done", validateStates},
+	{"workflow.wip", "", "WIP limits per state, e.g. in-progress:3,review:2", validateWIP},
+	{"urgency.priority", "6.0", "Urgency coefficient for priority (the top level counts in full)", validateFloat},
+	{"urgency.due", "12.0", "Urgency coefficient for due dates (a week overdue counts in full)", validateFloat},
+	{"urgency.age", "2.0", "Urgency coefficient for age (a year old counts in full)", validateFloat},
+	{"urgency.category", "", "Urgency added per category, e.g. work:2,someday:-3", validateCategoryWeights},
+	{"remind.offsets", "1d,1h", "When to remind before a todo is due, e.g. 1d,1h", validateOffsets},
+	{"remind.due_time", "09:00", "Time of day a due date falls due, for reminders", validateClockTime},
+	{"remind.notifier", "stdout", "How reminders are delivered: " + strings.Join(notifierKinds, ", "), validateOneOf(notifierKinds...)},
+	{"remind.exec", "", "Command run by the exec notifier, with TODO_* variables set", nil},
+	{"pomodoro.work", "25m", "Length of a pomodoro", validatePositiveDuration},
+	{"pomodoro.break", "5m", "Length of the break after each pomodoro", validateDuration},
+	{"pomodoro.cycles", "4", "Pomodoros in a session", validatePositiveInt},
+	{"db.path", "todo.db", "Path of the SQLite database", validateNonEmpty},
+}, themeConfigKeys()...)
+
+// themeConfigKeys adds a theme.<role> override for every color role.
+func themeConfigKeys() []configKey {
+	keys := make([]configKey, len(colorRoles))
+	for i, role := range colorRoles {
+		keys[i] = configKey{
+			Name:     "theme." + role,
+			Help:     "Color for " + strings.ReplaceAll(role, "_", " ") + " (name, 0-255 or #rrggbb)",
+			Validate: validateColorSpec,
+		}
+	}
+	return keys
+}
+
+// themeOverrides collects the theme.<role> settings that are set.
+func (c *Config) themeOverrides() map[string]string {
+	overrides := map[string]string{}
+	for _, role := range colorRoles {
+		if value := c.Get("theme." + role); value != "" {
+			overrides[role] = value
+		}
+	}
+	return overrides
+}
+
+// validatePriorityName only checks the form of a priority. Whether it is on
+// the scale depends on priority.levels, which loadConfig and Set check once
+// the other settings are known.
+func validatePriorityName(v string) error {
+	if v != "" && !priorityNameRe.MatchString(v) {
+		return fmt.Errorf("invalid priority: %s", v)
+	}
+	return nil
+}
+
+func validateOneOf(values ...string) func(string) error {
+	return func(v string) error {
+		for _, allowed := range values {
+			if v == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q. Use %s", v, strings.Join(values, ", "))
+	}
+}
+
+func validateSortKey(v string) error {
+	return validateOneOf(sortKeys...)(v)
+}
+
+func validateGroupKey(v string) error {
+	if v == "" {
+		return nil
+	}
+	return validateOneOf(groupKeys...)(v)
+}
+
+func validateBool(v string) error {
+	if _, err := strconv.ParseBool(v); err != nil {
+		return fmt.Errorf("invalid value %q. Use true or false", v)
+	}
+	return nil
+}
+
+func validateNonEmpty(v string) error {
+	if v == "" {
+		return fmt.Errorf("value can not be empty")
+	}
+	return nil
+}
+
+func validateDuration(v string) error {
+	_, err := parseDuration(v)
+	return err
+}
+
+func validatePositiveDuration(v string) error {
+	if d, err := parseDuration(v); err != nil || d <= 0 {
+		return fmt.Errorf("invalid duration %q. Use a positive duration, e.g. 25m", v)
+	}
+	return nil
+}
+
+func validatePositiveInt(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+		return fmt.Errorf("invalid number %q. Use a whole number above 0", v)
+	}
+	return nil
+}
+
+func lookupConfigKey(name string) (configKey, bool) {
+	for _, k := range configKeys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return configKey{}, false
+}
+
+// configEnvVar maps a key to its environment override, e.g. db.path to
+// TODO_DB_PATH.
+func configEnvVar(name string) string {
+	return "TODO_" + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+// Config holds the settings read from the config file. Lookups fall back to
+// the environment and the built-in defaults.
+type Config struct {
+	Path  string
+	file  map[string]string
+	lines []string
+
+	// project holds the settings of the active project, which override
+	// the file but not the environment.
+	project map[string]string
+
+	// scale caches the parsed priority scale; Set and useProject reset it.
+	scale *priorityScale
+}
+
+// cfg is the active configuration. It starts out with only the built-in
+// defaults so code running without loadConfig (such as tests) still works.
+var cfg = &Config{file: map[string]string{}}
+
+// defaultConfigPath is $TODO_CONFIG, or todo/config under the XDG config
+// directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("TODO_CONFIG"); path != "" {
+		return path
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "todo", "config")
+}
+
+// loadConfig reads a TOML-style config file. A missing file is not an
+// error. Only the subset of TOML needed for flat settings is understood:
+// [section] headers, key = value pairs, quoted or bare values and comments.
+func loadConfig(path string) (*Config, error) {
+	c := &Config{Path: path, file: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if err := c.checkEnv(); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	section := ""
+	lineOf := map[string]int{}
+	for i, raw := range c.lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := parseConfigLine(line)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, i+1)
+		}
+
+		name := key
+		if section != "" {
+			name = section + "." + key
+		}
+
+		k, known := lookupConfigKey(name)
+		if !known {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, i+1, name)
+		}
+		if k.Validate != nil {
+			if err := k.Validate(value); err != nil {
+				return nil, fmt.Errorf("%s:%d: %s: %v", path, i+1, name, err)
+			}
+		}
+
+		c.file[name] = value
+		lineOf[name] = i + 1
+	}
+
+	// The file may define the priority levels too, in any order
+	if p, ok := c.file["defaults.priority"]; ok && p != "" {
+		if err := c.priorityScale().check(Priority(p)); err != nil {
+			return nil, fmt.Errorf("%s:%d: defaults.priority: %v", path, lineOf["defaults.priority"], err)
+		}
+	}
+	if err := c.checkEnv(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// checkEnv validates the settings given in the environment, as the file's
+// are, including that a default priority is on the scale, which may itself
+// come from the file or the environment.
+func (c *Config) checkEnv() error {
+	for _, k := range configKeys {
+		name := configEnvVar(k.Name)
+		value := os.Getenv(name)
+		if value == "" || k.Validate == nil {
+			continue
+		}
+		if err := k.Validate(value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	name := configEnvVar("defaults.priority")
+	if p := os.Getenv(name); p != "" {
+		if err := c.priorityScale().check(Priority(p)); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// parseConfigLine splits a key = value line. A quoted value may be followed
+// by a # comment; after a bare value a comment starts at " # ", so values
+// such as "bold #ff8800" read whole but are best quoted.
+func parseConfigLine(line string) (string, string, bool) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, `"`) {
+		end := closingQuote(value)
+		if end < 0 {
+			return "", "", false
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		rest := strings.TrimSpace(value[end+1:])
+		if err != nil || (rest != "" && !strings.HasPrefix(rest, "#")) {
+			return "", "", false
+		}
+		return key, unquoted, key != ""
+	}
+
+	// Trailing comment after a bare value
+	if i := strings.Index(value+" ", " # "); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return key, value, key != ""
+}
+
+// closingQuote finds the quote that ends the string starting at s[0], or
+// returns -1 if there is none.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the effective value of a setting, ignoring flags.
+func (c *Config) Get(name string) string {
+	value, _ := c.Lookup(name)
+	return value
+}
+
+// Lookup returns the effective value of a setting and where it came from:
+// "env", "project", "file" or "default".
+func (c *Config) Lookup(name string) (string, string) {
+	if value, ok := os.LookupEnv(configEnvVar(name)); ok {
+		return value, "env"
+	}
+	if value, ok := c.project[name]; ok {
+		return value, "project"
+	}
+	if value, ok := c.file[name]; ok {
+		return value, "file"
+	}
+	k, _ := lookupConfigKey(name)
+	return k.Default, "default"
+}
+
+func (c *Config) Bool(name string) bool {
+	b, _ := strconv.ParseBool(c.Get(name))
+	return b
+}
+
+// Set validates value and writes it to the config file, keeping comments
+// and the layout of the other settings.
+func (c *Config) Set(name, value string) error {
+	if err := c.validate(name, value); err != nil {
+		return err
+	}
+	if c.Path == "" {
+		return fmt.Errorf("no config file location. Set TODO_CONFIG or XDG_CONFIG_HOME")
+	}
+
+	section, key, _ := strings.Cut(name, ".")
+	entry := key + " = " + strconv.Quote(value)
+
+	current := ""
+	sectionEnd := -1
+	replaced := false
+	for i, raw := range c.lines {
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			if current == section {
+				sectionEnd = i
+			}
+			continue
+		}
+		if current != section || line == "" {
+			continue
+		}
+
+		sectionEnd = i
+		if k, _, ok := parseConfigLine(line); ok && k == key && !strings.HasPrefix(line, "#") {
+			c.lines[i] = entry
+			replaced = true
+			break
+		}
+	}
+
+	if !replaced {
+		if sectionEnd >= 0 {
+			c.lines = append(c.lines[:sectionEnd+1], append([]string{entry}, c.lines[sectionEnd+1:]...)...)
+		} else {
+			if len(c.lines) > 0 {
+				c.lines = append(c.lines, "")
+			}
+			c.lines = append(c.lines, "["+section+"]", entry)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.Path, []byte(strings.Join(c.lines, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	c.file[name] = value
+	c.scale = nil
+	return nil
+}
+
+// validate checks value for setting name, including that a default
+// priority is on the priority scale.
+func (c *Config) validate(name, value string) error {
+	k, ok := lookupConfigKey(name)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	if k.Validate != nil {
+		if err := k.Validate(value); err != nil {
+			return err
+		}
+	}
+	if name == "defaults.priority" && value != "" {
+		if err := c.priorityScale().check(Priority(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configNames lists every setting in a stable order.
+func configNames() []string {
+	names := make([]string, len(configKeys))
+	for i, k := range configKeys {
+		names[i] = k.Name
+	}
+	sort.Strings(names)
+	return names
+}
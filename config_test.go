@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeTestConfig(t, `# my settings
+[defaults]
+priority = "high"
+category = work # trailing comment
+
+[list]
+sort = "due" # quoted, then a comment
+
+[remind]
+exec = "notify #todo" # the command, then a comment
+
+[theme]
+priority_high = bold #ff8800
+`)
+
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		wantValue  string
+		wantSource string
+	}{
+		{"defaults.priority", "high", "file"},
+		{"defaults.category", "work", "file"},
+		{"list.sort", "due", "file"},
+		{"remind.exec", "notify #todo", "file"},
+		{"theme.priority_high", "bold #ff8800", "file"},
+		{"list.filter", "pending", "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, source := c.Lookup(tt.name)
+			if value != tt.wantValue || source != tt.wantSource {
+				t.Errorf("Lookup(%q) = %q, %q; want %q, %q", tt.name, value, source, tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	c, err := loadConfig(filepath.Join(t.TempDir(), "nope"))
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if c.Get("db.path") != "todo.db" {
+		t.Errorf("db.path = %q, want built-in default", c.Get("db.path"))
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown setting", "[defaults]\nowner = \"me\"\n", "unknown setting"},
+		{"invalid priority", "[defaults]\npriority = \"urgent\"\n", ":2: defaults.priority: invalid priority"},
+		{"invalid bool", "[confirm]\ndelete = maybe\n", "use true or false"},
+		{"not key value", "[list]\nsort\n", "expected key = value"},
+		{"unterminated quote", "[list]\nsort = \"due\n", "expected key = value"},
+		{"text after quotes", "[list]\nsort = \"due\" title\n", "expected key = value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(writeTestConfig(t, tt.content))
+			if err == nil || !strings.Contains(strings.ToLower(err.Error()), strings.ToLower(tt.wantErr)) {
+				t.Errorf("loadConfig() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidEnv(t *testing.T) {
+	t.Setenv("TODO_LIST_SORT", "sideways")
+	_, err := loadConfig(filepath.Join(t.TempDir(), "nope"))
+	if err == nil || !strings.Contains(err.Error(), "TODO_LIST_SORT: ") {
+		t.Errorf("loadConfig() with an invalid TODO_LIST_SORT error = %v", err)
+	}
+}
+
+func TestConfig_EnvOverridesFile(t *testing.T) {
+	c, _ := loadConfig(writeTestConfig(t, "[list]\nsort = \"due\"\n"))
+	t.Setenv("TODO_LIST_SORT", "title")
+
+	value, source := c.Lookup("list.sort")
+	if value != "title" || source != "env" {
+		t.Errorf("Lookup() = %q, %q; want %q, %q", value, source, "title", "env")
+	}
+}
+
+func TestConfig_Set(t *testing.T) {
+	path := writeTestConfig(t, "# keep me\n[list]\nsort = \"due\"\n\n[confirm]\n")
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	if err := c.Set("list.sort", "priority"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("confirm.delete", "false"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("db.path", "/tmp/todo.db"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set("list.sort", "random"); err == nil {
+		t.Error("Set() with invalid value should fail")
+	}
+	if err := c.Set("no.such", "x"); err == nil {
+		t.Error("Set() with unknown setting should fail")
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "# keep me\n[list]\nsort = \"priority\"\n\n[confirm]\ndelete = \"false\"\n\n[db]\npath = \"/tmp/todo.db\"\n"
+	if string(data) != want {
+		t.Errorf("config file =\n%s\nwant\n%s", data, want)
+	}
+
+	reloaded, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("reloading config error = %v", err)
+	}
+	if reloaded.Bool("confirm.delete") {
+		t.Error("confirm.delete should be false after reload")
+	}
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	t.Setenv("TODO_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if got := defaultConfigPath(); got != "/xdg/todo/config" {
+		t.Errorf("defaultConfigPath() = %q, want %q", got, "/xdg/todo/config")
+	}
+
+	t.Setenv("TODO_CONFIG", "/custom/config")
+	if got := defaultConfigPath(); got != "/custom/config" {
+		t.Errorf("defaultConfigPath() = %q, want %q", got, "/custom/config")
+	}
+}
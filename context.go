@@ -0,0 +1,271 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// todoContext is a named filter, such as "work", that scopes list, show
+// and clear to the todos it matches and gives add its defaults.
+type todoContext struct {
+	Name     string
+	Category string
+	Priority Priority
+}
+
+// activeContext is the context in use, from --context or context.active,
+// or nil for none.
+var activeContext *todoContext
+
+func validateContextName(v string) error {
+	if v != "" && !nameRe.MatchString(v) {
+		return fmt.Errorf("invalid context name %q. Use letters, digits, ., - and _", v)
+	}
+	return nil
+}
+
+func createContextsTable() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS contexts (
+		name TEXT PRIMARY KEY,
+		category TEXT NOT NULL DEFAULT '',
+		priority TEXT NOT NULL DEFAULT ''
+	)`)
+	return err
+}
+
+// matches reports whether todo falls inside the context.
+func (c *todoContext) matches(todo Todo) bool {
+	if c == nil {
+		return true
+	}
+	return (c.Category == "" || todo.Category == c.Category) &&
+		(c.Priority == "" || todo.Priority == c.Priority)
+}
+
+// condition is the SQL condition for the todos in the context, or "" when
+// there is no context.
+func (c *todoContext) condition() (string, []any) {
+	if c == nil {
+		return "", nil
+	}
+
+	var conds []string
+	var args []any
+	if c.Category != "" {
+		conds = append(conds, "category = ?")
+		args = append(args, c.Category)
+	}
+	if c.Priority != "" {
+		conds = append(conds, "priority = ?")
+		args = append(args, string(c.Priority))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// describe lists the filters of the context, e.g. "category work, priority high".
+func (c *todoContext) describe() string {
+	var parts []string
+	if c.Category != "" {
+		parts = append(parts, "category "+c.Category)
+	}
+	if c.Priority != "" {
+		parts = append(parts, "priority "+string(c.Priority))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func getContexts() ([]todoContext, error) {
+	rows, err := db.Query(`SELECT name, category, priority FROM contexts ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contexts []todoContext
+	for rows.Next() {
+		var c todoContext
+		if err := rows.Scan(&c.Name, &c.Category, &c.Priority); err != nil {
+			return nil, err
+		}
+		contexts = append(contexts, c)
+	}
+	return contexts, rows.Err()
+}
+
+func getContext(name string) (*todoContext, error) {
+	var c todoContext
+	err := db.QueryRow(`SELECT name, category, priority FROM contexts WHERE name = ?`, name).Scan(&c.Name, &c.Category, &c.Priority)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("context %q not found. Use context define to add it", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// defineContext creates the context or replaces its filters, reporting
+// whether it already existed.
+func defineContext(c todoContext) (bool, error) {
+	_, err := getContext(c.Name)
+	existed := err == nil
+
+	_, err = db.Exec(`
+	INSERT INTO contexts (name, category, priority) VALUES (?, ?, ?)
+	ON CONFLICT (name) DO UPDATE SET category = excluded.category, priority = excluded.priority`,
+		c.Name, c.Category, string(c.Priority))
+	return existed, err
+}
+
+func deleteContext(name string) error {
+	_, err := db.Exec(`DELETE FROM contexts WHERE name = ?`, name)
+	return err
+}
+
+// useContext makes the named context the active one. An empty name leaves
+// no context active.
+func useContext(name string) error {
+	if name == "" {
+		activeContext = nil
+		return nil
+	}
+
+	c, err := getContext(name)
+	if err != nil {
+		return err
+	}
+	activeContext = c
+	return nil
+}
+
+// addDefaults is the priority and category add uses unless told otherwise:
+// the active context's filters, falling back to the configured defaults.
+func addDefaults() (Priority, string) {
+	priority, category := defaultPriority(), cfg.Get("defaults.category")
+	if activeContext != nil {
+		if activeContext.Priority != "" {
+			priority = activeContext.Priority
+		}
+		if activeContext.Category != "" {
+			category = activeContext.Category
+		}
+	}
+	return priority, category
+}
+
+// contextHeader names the active context for list headers.
+func contextHeader() string {
+	if activeContext == nil {
+		return ""
+	}
+	return " (context " + activeContext.Name + ")"
+}
+
+func cmdContextDefine(name, category string, priority Priority) error {
+	if name == "" {
+		return fmt.Errorf("context name can not be empty")
+	}
+	if err := validateContextName(name); err != nil {
+		return err
+	}
+	if category == "" && priority == "" {
+		return fmt.Errorf("a context needs a filter. Use --category or --priority")
+	}
+	if priority != "" {
+		if err := currentPriorities().check(priority); err != nil {
+			return err
+		}
+	}
+
+	c := todoContext{Name: name, Category: category, Priority: priority}
+	existed, err := defineContext(c)
+	if err != nil {
+		return err
+	}
+	verb := "Defined"
+	if existed {
+		verb = "Redefined"
+	}
+	fmt.Printf("%s %s context %s: %s\n", colorize(Green, "✓"), verb, name, c.describe())
+	return nil
+}
+
+// cmdContextUse saves the named context as the active one, or clears it
+// when name is empty.
+func cmdContextUse(name string) error {
+	if name != "" {
+		if _, err := getContext(name); err != nil {
+			return err
+		}
+	}
+	if err := cfg.Set("context.active", name); err != nil {
+		return err
+	}
+
+	if name == "" {
+		fmt.Printf("%s No context in use, showing all todos\n", colorize(Green, "✓"))
+	} else {
+		fmt.Printf("%s Using context %s\n", colorize(Green, "✓"), name)
+	}
+	if _, source := cfg.Lookup("context.active"); source == "env" {
+		fmt.Printf("Note: %s is set in the environment and takes precedence\n", configEnvVar("context.active"))
+	}
+	return nil
+}
+
+func cmdContextList() error {
+	contexts, err := getContexts()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nContexts:")
+	fmt.Println("---------------------------------------")
+	if len(contexts) == 0 {
+		fmt.Println("No contexts. Use context define <name> --category ... to add one")
+		return nil
+	}
+
+	table := NewTable([]string{"Name", "Category", "Priority"})
+	for _, c := range contexts {
+		name := c.Name
+		if activeContext != nil && activeContext.Name == c.Name {
+			name = colorize(Bold, c.Name+" *")
+		}
+		table.AddRow([]string{name, c.Category, string(c.Priority)})
+	}
+	table.Print()
+
+	if activeContext != nil {
+		fmt.Println("* active context")
+	}
+	return nil
+}
+
+// cmdContextShow tells which context is in use.
+func cmdContextShow() error {
+	if activeContext == nil {
+		fmt.Println("No context in use. Use context use <name> to pick one")
+		return nil
+	}
+	fmt.Printf("Context %s: %s\n", activeContext.Name, activeContext.describe())
+	return nil
+}
+
+func cmdContextDelete(name string) error {
+	if _, err := getContext(name); err != nil {
+		return err
+	}
+	if activeContext != nil && activeContext.Name == name {
+		return fmt.Errorf("context %s is in use. Use context none first", name)
+	}
+
+	if err := deleteContext(name); err != nil {
+		return err
+	}
+	fmt.Printf("%s Deleted context %s\n", colorize(Green, "✓"), name)
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// enterContext makes name the active context for the rest of the test.
+func enterContext(t *testing.T, name string) {
+	if err := useContext(name); err != nil {
+		t.Fatalf("useContext(%q) error = %v", name, err)
+	}
+	t.Cleanup(func() { useContext("") })
+}
+
+func TestCmdContextDefine(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	tests := []struct {
+		name     string
+		category string
+		priority Priority
+		want     string
+		wantErr  bool
+	}{
+		{name: "work", category: "work", priority: PriorityHigh, want: "Defined context work: category work, priority high"},
+		{name: "home", category: "home", want: "Defined context home: category home"},
+		{name: "home", category: "house", want: "Redefined context home: category house"},
+		{name: "urgent", priority: "critical", wantErr: true},
+		{name: "empty", wantErr: true},
+		{name: "two words", category: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var err error
+		out := stripAnsi(captureOutput(func() { err = cmdContextDefine(tt.name, tt.category, tt.priority) }))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cmdContextDefine(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !strings.Contains(out, tt.want) {
+			t.Errorf("cmdContextDefine(%q) output = %q, want %q", tt.name, out, tt.want)
+		}
+	}
+
+	contexts, _ := getContexts()
+	if len(contexts) != 2 || contexts[0].Name != "home" || contexts[0].Category != "house" {
+		t.Errorf("getContexts() = %+v", contexts)
+	}
+	if err := useContext("missing"); err == nil || !strings.Contains(err.Error(), "context define") {
+		t.Errorf("useContext(missing) error = %v", err)
+	}
+}
+
+func TestContextMatches(t *testing.T) {
+	work := &todoContext{Name: "work", Category: "work", Priority: PriorityHigh}
+	anyWork := &todoContext{Name: "any", Category: "work"}
+
+	tests := []struct {
+		ctx  *todoContext
+		todo Todo
+		want bool
+	}{
+		{ctx: nil, todo: Todo{Category: "home"}, want: true},
+		{ctx: work, todo: Todo{Category: "work", Priority: PriorityHigh}, want: true},
+		{ctx: work, todo: Todo{Category: "work", Priority: PriorityLow}, want: false},
+		{ctx: work, todo: Todo{Category: "home", Priority: PriorityHigh}, want: false},
+		{ctx: anyWork, todo: Todo{Category: "work", Priority: PriorityLow}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ctx.matches(tt.todo); got != tt.want {
+			t.Errorf("%+v.matches(%+v) = %v, want %v", tt.ctx, tt.todo, got, tt.want)
+		}
+	}
+}
+
+func TestContextScopesCommands(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	captureOutput(func() { cmdContextDefine("work", "work", PriorityHigh) })
+	insertTestTodo(t, "Ship release", PriorityHigh, "work", "")
+	insertTestTodo(t, "Tidy wiki", PriorityLow, "work", "")
+	insertTestTodo(t, "Groceries", PriorityHigh, "home", "")
+	enterContext(t, "work")
+
+	out := stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "Pending Todos (context work):") || !strings.Contains(out, "Ship release") {
+		t.Errorf("cmdList() in a context:\n%s", out)
+	}
+	for _, hidden := range []string{"Tidy wiki", "Groceries"} {
+		if strings.Contains(out, hidden) {
+			t.Errorf("cmdList() in a context should hide %q:\n%s", hidden, out)
+		}
+	}
+
+	// An explicit filter wins over the context's
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{Priority: PriorityLow}) }))
+	if !strings.Contains(out, "Tidy wiki") || strings.Contains(out, "Ship release") {
+		t.Errorf("cmdList(--priority low) in a context:\n%s", out)
+	}
+
+	if err := cmdShow(3); err == nil || !strings.Contains(err.Error(), "outside context work") {
+		t.Errorf("cmdShow() outside the context error = %v", err)
+	}
+	captureOutput(func() {
+		if err := cmdShow(1); err != nil {
+			t.Errorf("cmdShow() inside the context error = %v", err)
+		}
+	})
+
+	markTodoAsDone(1)
+	markTodoAsDone(3)
+	out = captureOutput(func() {
+		if err := cmdClear(false, "", true); err != nil {
+			t.Fatalf("cmdClear() error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "Cleared 1 completed todos in context work") {
+		t.Errorf("cmdClear() output = %q", out)
+	}
+	if _, err := getTodoByID(3); err != nil {
+		t.Errorf("cmdClear() should keep done todos outside the context")
+	}
+}
+
+func TestContextScopesBulk(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	captureOutput(func() { cmdContextDefine("w", "work", "") })
+	insertTestTodo(t, "Ship release", PriorityHigh, "work", "")
+	insertTestTodo(t, "Groceries", PriorityHigh, "home", "")
+	enterContext(t, "w")
+
+	captureOutput(func() {
+		if err := cmdBulkStatus(todoSelection{Pending: true}, true, bulkOptions{Force: true}); err != nil {
+			t.Fatalf("cmdBulkStatus() error = %v", err)
+		}
+	})
+	if todo, _ := getTodoByID(1); !todo.Done {
+		t.Errorf("done --pending in context w should mark #1 done")
+	}
+	if todo, _ := getTodoByID(2); todo.Done {
+		t.Errorf("done --pending in context w marked the home todo done")
+	}
+
+	captureOutput(func() { cmdBulkDelete(todoSelection{Priority: PriorityHigh}, bulkOptions{Force: true}) })
+	if _, err := getTodoByID(2); err != nil {
+		t.Errorf("delete --priority high in context w deleted the home todo")
+	}
+}
+
+func TestContextAddDefaults(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	captureOutput(func() { cmdContextDefine("work", "work", PriorityHigh) })
+	captureOutput(func() { cmdContextDefine("errands", "errands", "") })
+	t.Setenv("TODO_DEFAULTS_CATEGORY", "inbox")
+
+	tests := []struct {
+		context      string
+		wantPriority Priority
+		wantCategory string
+	}{
+		{context: "", wantPriority: defaultPriority(), wantCategory: "inbox"},
+		{context: "work", wantPriority: PriorityHigh, wantCategory: "work"},
+		{context: "errands", wantPriority: defaultPriority(), wantCategory: "errands"},
+	}
+
+	for _, tt := range tests {
+		enterContext(t, tt.context)
+		if priority, category := addDefaults(); priority != tt.wantPriority || category != tt.wantCategory {
+			t.Errorf("addDefaults() in %q = %s, %q, want %s, %q", tt.context, priority, category, tt.wantPriority, tt.wantCategory)
+		}
+	}
+}
+
+func TestCmdContextUse(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	saved := cfg
+	defer func() { cfg = saved }()
+
+	loaded, err := loadConfig(t.TempDir() + "/config")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	cfg = loaded
+	t.Setenv("TODO_CONTEXT_ACTIVE", "")
+	captureOutput(func() { cmdContextDefine("work", "work", "") })
+
+	if err := cmdContextUse("missing"); err == nil {
+		t.Errorf("cmdContextUse() of a missing context should fail")
+	}
+
+	out := stripAnsi(captureOutput(func() {
+		if err := cmdContextUse("work"); err != nil {
+			t.Fatalf("cmdContextUse() error = %v", err)
+		}
+	}))
+	if !strings.Contains(out, "Using context work") || !strings.Contains(out, "TODO_CONTEXT_ACTIVE is set in the environment") {
+		t.Errorf("cmdContextUse() output = %q", out)
+	}
+
+	reloaded, err := loadConfig(cfg.Path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if value, source := reloaded.Lookup("context.active"); value != "" || source != "env" {
+		t.Errorf("context.active = %q from %s", value, source)
+	}
+	if reloaded.file["context.active"] != "work" {
+		t.Errorf("config file context.active = %q, want work", reloaded.file["context.active"])
+	}
+
+	enterContext(t, "work")
+	if err := cmdContextDelete("work"); err == nil {
+		t.Errorf("cmdContextDelete() of the context in use should fail")
+	}
+	useContext("")
+	captureOutput(func() {
+		if err := cmdContextDelete("work"); err != nil {
+			t.Errorf("cmdContextDelete() error = %v", err)
+		}
+	})
+}
@@ -2,19 +2,28 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 var db *sql.DB
 
-func getTodoByID(id int) (*Todo, error) {
-	query := `SELECT id, title, done, priority, category, created_at, due_date FROM todos WHERE id = ?`
-	row := db.QueryRow(query, id)
+// querier is satisfied by both *sql.DB and *sql.Tx so lookups can run
+// inside or outside a transaction.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+const todoColumns = `id, title, done, priority, category, created_at, due_date, notes, completed_at, state, wait_until, estimate, project_id`
 
-	todo, err := scanTodo(row)
+func getTodoByID(id int) (*Todo, error) {
+	todo, err := fetchTodo(db, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("todo #%d not found", id)
@@ -25,32 +34,74 @@ func getTodoByID(id int) (*Todo, error) {
 	return todo, nil
 }
 
+func fetchTodo(q querier, id int) (*Todo, error) {
+	row := q.QueryRow(`SELECT `+todoColumns+` FROM todos WHERE id = ?`, id)
+	return scanTodo(row)
+}
+
+// todoFilter selects todos for getTodos. The zero value is the pending
+// todos that aren't snoozed, outside archived projects.
+type todoFilter struct {
+	ShowAll  bool
+	ShowDone bool
+	// Snoozed includes pending todos that are snoozed.
+	Snoozed  bool
+	Priority Priority
+	Category string
+	// Project limits the todos to one project; 0 leaves out the todos in
+	// archived projects instead.
+	Project int
+}
+
+// getAllTodos returns the pending or done todos with the given priority
+// and category, leaving out snoozed ones. getTodos takes the full filter.
 func getAllTodos(showAll, showDone bool, priority Priority, category string) ([]Todo, error) {
-	query := `SELECT id, title, done, priority, category, created_at, due_date FROM todos`
+	return getTodos(todoFilter{ShowAll: showAll, ShowDone: showDone, Priority: priority, Category: category})
+}
+
+// getTodos returns the todos matching f.
+func getTodos(f todoFilter) ([]Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM todos`
 	conditions := []string{}
 	args := []any{}
 
-	if showDone {
+	if !f.Snoozed {
+		conditions = append(conditions, "(done = 1 OR wait_until IS NULL OR wait_until <= ?)")
+		args = append(args, storedTime(clock()))
+	}
+
+	if f.ShowDone {
 		conditions = append(conditions, "done = 1")
-	} else if !showAll {
+	} else if !f.ShowAll {
 		conditions = append(conditions, "done = 0")
 	}
 
-	if category != "" {
+	if f.Category != "" {
 		conditions = append(conditions, "category = ?")
-		args = append(args, category)
+		args = append(args, f.Category)
 	}
 
-	if priority != "" {
+	if f.Priority != "" {
 		conditions = append(conditions, "priority = ?")
-		args = append(args, string(priority))
+		args = append(args, string(f.Priority))
+	}
+
+	if f.Project != 0 {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, f.Project)
+	} else {
+		conditions = append(conditions, "project_id NOT IN (SELECT id FROM projects WHERE archived = 1)")
 	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	rows, err := db.Query(query, args...)
+	return queryTodos(db, query, args...)
+}
+
+func queryTodos(q querier, query string, args ...any) ([]Todo, error) {
+	rows, err := q.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -58,18 +109,11 @@ func getAllTodos(showAll, showDone bool, priority Priority, category string) ([]
 
 	var todos []Todo
 	for rows.Next() {
-		var todo Todo
-		var done int
-		var priority string
-
-		err := rows.Scan(&todo.ID, &todo.Title, &done, &priority, &todo.Category, &todo.CreatedAt, &todo.DueDate)
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		todo.Done = done == 1
-		todo.Priority = Priority(priority)
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -79,12 +123,17 @@ func getAllTodos(showAll, showDone bool, priority Priority, category string) ([]
 	return todos, nil
 }
 
-func scanTodo(row *sql.Row) (*Todo, error) {
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTodo(row rowScanner) (*Todo, error) {
 	var todo Todo
 	var done int
 	var priority string
 
-	err := row.Scan(&todo.ID, &todo.Title, &done, &priority, &todo.Category, &todo.CreatedAt, &todo.DueDate)
+	err := row.Scan(&todo.ID, &todo.Title, &done, &priority, &todo.Category, &todo.CreatedAt, &todo.DueDate, &todo.Notes, &todo.CompletedAt, &todo.State, &todo.WaitUntil, &todo.Estimate, &todo.ProjectID)
 	if err != nil {
 		return nil, err
 	}
@@ -95,22 +144,38 @@ func scanTodo(row *sql.Row) (*Todo, error) {
 }
 
 func insertTodo(title string, priority Priority, category, dueDate string) (int64, error) {
-	var result sql.Result
-	var err error
+	return insertTodoWith(title, priority, category, dueDate, effort{}, 0)
+}
 
-	if dueDate != "" {
-		query := `INSERT INTO todos (title, priority, category, due_date) VALUES (?, ?, ?, ?)`
-		result, err = db.Exec(query, title, string(priority), category, dueDate)
-	} else {
-		query := `INSERT INTO todos (title, priority, category) VALUES (?, ?, ?)`
-		result, err = db.Exec(query, title, string(priority), category)
-	}
+// insertTodoWith adds a todo together with its estimate and project, so
+// undo removes it in one step.
+func insertTodoWith(title string, priority Priority, category, dueDate string, estimate effort, project int) (int64, error) {
+	var id int64
+	err := withJournal("add", func(b *journalBatch) error {
+		result, err := b.tx.Exec(
+			`INSERT INTO todos (title, priority, category, due_date, estimate, project_id) VALUES (?, ?, ?, ?, ?, ?)`,
+			title, string(priority), category, fieldValue("due_date", dueDate), estimate.String(), project,
+		)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
 
+		todo, err := fetchTodo(b.tx, int(id))
+		if err != nil {
+			return err
+		}
+		return b.insert(todo)
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	return id, nil
 }
 
 func markTodoAsDone(id int) error {
@@ -121,30 +186,124 @@ func markTodoAsUndone(id int) error {
 	return setTodoStatus(id, 0)
 }
 
+func statusCommand(done int) string {
+	if done == 0 {
+		return "undone"
+	}
+	return "done"
+}
+
 func setTodoStatus(id int, done int) error {
-	result, err := db.Exec(`UPDATE todos SET done = ? WHERE id = ?`, done, id)
-	if err != nil {
-		return err
+	return withJournal(statusCommand(done), func(b *journalBatch) error {
+		return setTodoStatusIn(b, id, done)
+	})
+}
+
+// setTodoStatusIn stamps completed_at when a todo is first marked done and
+// clears it when the todo is reopened. Todos that have been moved through
+// the workflow go to its terminal or initial state.
+func setTodoStatusIn(b *journalBatch, id int, done int) error {
+	w := currentWorkflow()
+	if done == 0 {
+		return updateTodoRow(b, id,
+			`UPDATE todos SET done = 0, completed_at = NULL,
+				state = CASE WHEN state = '' OR done = 0 THEN state ELSE ? END
+			WHERE id = ?`,
+			w.initial(), id,
+		)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := stopTimerOn(b.tx, clock(), "WHERE id = ?", id); err != nil {
 		return err
 	}
+	return updateTodoRow(b, id,
+		`UPDATE todos SET done = 1,
+			completed_at = CASE WHEN done = 1 AND completed_at IS NOT NULL THEN completed_at ELSE ? END,
+			state = CASE WHEN state = '' THEN '' ELSE ? END
+		WHERE id = ?`,
+		clock().UTC(), w.terminal(), id,
+	)
+}
+
+// moveTodo puts a todo in a workflow state. Moving to the terminal state
+// completes the todo and moving out of it reopens it.
+func moveTodo(id int, state string) error {
+	return withJournal("move", func(b *journalBatch) error {
+		if state == currentWorkflow().terminal() {
+			if err := setTodoStatusIn(b, id, 1); err != nil {
+				return err
+			}
+			return updateTodoRow(b, id, `UPDATE todos SET state = ? WHERE id = ?`, state, id)
+		}
+
+		return updateTodoRow(b, id,
+			`UPDATE todos SET state = ?, done = 0, completed_at = NULL WHERE id = ?`,
+			state, id,
+		)
+	})
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("todo #%d not found", id)
+// snoozeTodo hides a todo from the pending list until the given time. A
+// zero time wakes it up again.
+func snoozeTodo(id int, until time.Time) error {
+	command, value := "snooze", any(storedTime(until))
+	if until.IsZero() {
+		command, value = "unsnooze", nil
 	}
 
-	return nil
+	return withJournal(command, func(b *journalBatch) error {
+		return updateTodoRow(b, id, `UPDATE todos SET wait_until = ? WHERE id = ?`, value, id)
+	})
+}
+
+func setTodoEstimate(id int, estimate effort) error {
+	return withJournal("estimate", func(b *journalBatch) error {
+		return updateTodoRow(b, id, `UPDATE todos SET estimate = ? WHERE id = ?`, estimate.String(), id)
+	})
+}
+
+// storedTime is how wait_until and the time entry times are stored: UTC
+// to the second, so the columns compare correctly as text.
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
 }
 
 func deleteTodo(id int) error {
-	_, err := db.Exec("DELETE FROM todos WHERE id = ?", id)
-	return err
+	return withJournal("delete", func(b *journalBatch) error {
+		err := deleteTodoIn(b, id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
+}
+
+// deleteTodoIn returns sql.ErrNoRows if the todo doesn't exist.
+func deleteTodoIn(b *journalBatch, id int) error {
+	todo, err := fetchTodo(b.tx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := stopTimerOn(b.tx, clock(), "WHERE id = ?", id); err != nil {
+		return err
+	}
+
+	_, err = b.tx.Exec("DELETE FROM todos WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return b.delete(todo)
 }
 
 func updateTodo(id int, title string, priority Priority, category, dueDate string) error {
+	return withJournal("edit", func(b *journalBatch) error {
+		return updateTodoIn(b, id, title, priority, category, dueDate)
+	})
+}
+
+func updateTodoIn(b *journalBatch, id int, title string, priority Priority, category, dueDate string) error {
 	updates := []string{}
 	args := []any{}
 
@@ -175,29 +334,177 @@ func updateTodo(id int, title string, priority Priority, category, dueDate strin
 	query := "UPDATE todos SET " + strings.Join(updates, ", ") + " WHERE id = ?"
 	args = append(args, id)
 
-	_, err := db.Exec(query, args...)
-	return err
+	return updateTodoRow(b, id, query, args...)
+}
+
+// updateTodoRow runs query against todo id and journals whatever it changed.
+func updateTodoRow(b *journalBatch, id int, query string, args ...any) error {
+	before, err := fetchTodo(b.tx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("todo #%d not found", id)
+		}
+		return err
+	}
+
+	_, err = b.tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return b.changes(before)
 }
 
+// saveTodo overwrites every editable field of todo in one update, including
+// clearing fields that are empty.
+func saveTodo(todo *Todo) error {
+	dueDate := ""
+	if todo.DueDate.Valid {
+		dueDate = todo.DueDate.Time.Format("2006-01-02")
+	}
+
+	return withJournal("edit", func(b *journalBatch) error {
+		return updateTodoRow(b, todo.ID,
+			`UPDATE todos SET title = ?, priority = ?, category = ?, due_date = ?, estimate = ?, notes = ? WHERE id = ?`,
+			todo.Title, string(todo.Priority), todo.Category, fieldValue("due_date", dueDate), todo.Estimate, todo.Notes, todo.ID,
+		)
+	})
+}
+
+func setTodoNotes(id int, notes string) error {
+	return withJournal("note", func(b *journalBatch) error {
+		return updateTodoRow(b, id, `UPDATE todos SET notes = ? WHERE id = ?`, notes, id)
+	})
+}
+
+// The count and clear helpers only see the todos in scope: those of the
+// active project and context, leaving out archived projects when no
+// project is active, as list does.
+
 func countAllTodos() (int, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM todos").Scan(&count)
-	return count, err
+	where, args := scopedWhere("")
+	return countTodos(where, args...)
 }
 
 func countCompletedTodos() (int, error) {
+	where, args := scopedWhere("done = 1")
+	return countTodos(where, args...)
+}
+
+func countCompletedBefore(before time.Time) (int, error) {
+	where, args := scopedWhere("done = 1 AND completed_at < ?", before.UTC())
+	return countTodos(where, args...)
+}
+
+// countTodos counts the todos matching where.
+func countTodos(where string, args ...any) (int, error) {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM todos WHERE done = 1").Scan(&count)
+	err := db.QueryRow("SELECT COUNT(*) FROM todos "+where, args...).Scan(&count)
 	return count, err
 }
 
 func clearAllTodos() error {
-	_, err := db.Exec("DELETE FROM todos")
-	return err
+	where, args := scopedWhere("")
+	return clearTodos("clear --all", where, args...)
 }
 
 func clearCompletedTodos() error {
-	_, err := db.Exec("DELETE FROM todos WHERE done = 1")
+	where, args := scopedWhere("done = 1")
+	return clearTodos("clear", where, args...)
+}
+
+// clearCompletedBefore removes todos that were completed before a point in
+// time. Completed todos without a completion time are kept.
+func clearCompletedBefore(before time.Time) error {
+	where, args := scopedWhere("done = 1 AND completed_at < ?", before.UTC())
+	return clearTodos("clear --older-than", where, args...)
+}
+
+// scopedWhere builds a WHERE clause of cond, if any, limited to the active
+// project and context.
+func scopedWhere(cond string, args ...any) (string, []any) {
+	var conds []string
+	if cond != "" {
+		conds = append(conds, cond)
+	}
+
+	if id := activeProjectID(); id != 0 {
+		conds = append(conds, "project_id = ?")
+		args = append(args, id)
+	} else {
+		conds = append(conds, "project_id NOT IN (SELECT id FROM projects WHERE archived = 1)")
+	}
+
+	if c, cargs := activeContext.condition(); c != "" {
+		conds = append(conds, c)
+		args = append(args, cargs...)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// clearTodos deletes every todo matching where, journaling each row so the
+// whole clear can be undone in one step.
+func clearTodos(command, where string, args ...any) error {
+	return withJournal(command, func(b *journalBatch) error {
+		todos, err := queryTodos(b.tx, `SELECT `+todoColumns+` FROM todos `+where, args...)
+		if err != nil {
+			return err
+		}
+
+		if err := stopTimerOn(b.tx, clock(), where, args...); err != nil {
+			return err
+		}
+
+		_, err = b.tx.Exec("DELETE FROM todos "+where, args...)
+		if err != nil {
+			return err
+		}
+
+		for i := range todos {
+			if err := b.delete(&todos[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// todoMigrations lists columns added after the original schema, so
+// databases created by older versions are upgraded in place.
+var todoMigrations = []struct {
+	column     string
+	definition string
+}{
+	{"notes", "TEXT NOT NULL DEFAULT ''"},
+	{"completed_at", "DATETIME"},
+	{"state", "TEXT NOT NULL DEFAULT ''"},
+	{"wait_until", "DATETIME"},
+	{"estimate", "TEXT NOT NULL DEFAULT ''"},
+	{"project_id", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+func addColumnIfMissing(table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
@@ -214,14 +521,79 @@ func createTables() error {
 	)`
 
 	_, err := db.Exec(createTableSQL)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range todoMigrations {
+		err = addColumnIfMissing("todos", m.column, m.definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = createJournalTable()
+	if err != nil {
+		return err
+	}
+
+	err = createHistoryTable()
+	if err != nil {
+		return err
+	}
+
+	err = createRemindersTable()
+	if err != nil {
+		return err
+	}
+
+	err = createTimeEntriesTable()
+	if err != nil {
+		return err
+	}
+
+	err = createProjectsTables()
+	if err != nil {
+		return err
+	}
+
+	err = createContextsTable()
+	if err != nil {
+		return err
+	}
+
+	return backfillCompletedAt()
+}
+
+// backfillCompletedAt gives todos completed before completed_at existed the
+// time history last recorded them as done.
+func backfillCompletedAt() error {
+	_, err := db.Exec(`
+	UPDATE todos SET completed_at = (
+		SELECT MAX(changed_at) FROM history
+		WHERE history.todo_id = todos.id AND field = 'done' AND new_value = '1'
+	)
+	WHERE done = 1 AND completed_at IS NULL`)
 	return err
 }
 
-func initDB() error {
+// isBusy reports whether err is SQLite finding the database locked by
+// another connection, which passes once that connection is done.
+func isBusy(err error) bool {
+	var e sqlite3.Error
+	return errors.As(err, &e) && (e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked)
+}
+
+func initDB(path string) error {
 	var err error
 
-	// open database
-	db, err = sql.Open("sqlite3", "todo.db")
+	// open database, waiting a while when another todo command holds a
+	// lock instead of failing straight away
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err = sql.Open("sqlite3", path+sep+"_busy_timeout=5000")
 	if err != nil {
 		return err
 	}
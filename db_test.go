@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 )
 
 func TestInsertTodo(t *testing.T) {
@@ -234,6 +235,22 @@ func TestMarkTodoAsDone(t *testing.T) {
 	if !todo.Done {
 		t.Errorf("Todo should be done, but Done = %v", todo.Done)
 	}
+	if !todo.CompletedAt.Valid || time.Since(todo.CompletedAt.Time) > time.Minute {
+		t.Errorf("CompletedAt = %v, want about now", todo.CompletedAt)
+	}
+
+	// Marking it done again keeps the original completion time
+	completed := time.Now().Add(-48 * time.Hour).UTC()
+	if _, err := db.Exec(`UPDATE todos SET completed_at = ? WHERE id = 1`, completed); err != nil {
+		t.Fatalf("failed to age completion: %v", err)
+	}
+	if err := markTodoAsDone(1); err != nil {
+		t.Fatalf("markTodoAsDone() error = %v", err)
+	}
+	todo, _ = getTodoByID(1)
+	if !todo.CompletedAt.Time.Equal(completed) {
+		t.Errorf("CompletedAt = %v, want unchanged %v", todo.CompletedAt.Time, completed)
+	}
 }
 
 func TestMarkTodoAsUndone(t *testing.T) {
@@ -252,6 +269,9 @@ func TestMarkTodoAsUndone(t *testing.T) {
 	if todo.Done {
 		t.Errorf("Todo should be undone, but Done = %v", todo.Done)
 	}
+	if todo.CompletedAt.Valid {
+		t.Errorf("CompletedAt = %v, want cleared", todo.CompletedAt.Time)
+	}
 }
 
 func TestMarkTodoAsDone_NotFound(t *testing.T) {
@@ -434,6 +454,41 @@ func TestClearCompletedTodos(t *testing.T) {
 	}
 }
 
+func TestClearCompletedBefore(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Long done", PriorityMedium, "", "")
+	insertTestTodo(t, "Just done", PriorityMedium, "", "")
+	insertTestTodo(t, "Pending", PriorityMedium, "", "")
+	markTodoAsDone(1)
+	markTodoAsDone(2)
+
+	_, err := db.Exec(`UPDATE todos SET completed_at = ? WHERE id = 1`, time.Now().AddDate(0, 0, -40).UTC())
+	if err != nil {
+		t.Fatalf("failed to age completion: %v", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -30)
+	count, err := countCompletedBefore(cutoff)
+	if err != nil || count != 1 {
+		t.Fatalf("countCompletedBefore() = %d, %v, want 1", count, err)
+	}
+
+	if err := clearCompletedBefore(cutoff); err != nil {
+		t.Fatalf("clearCompletedBefore() error = %v", err)
+	}
+
+	if _, err := getTodoByID(1); err == nil {
+		t.Errorf("todo #1 should have been cleared")
+	}
+	for _, id := range []int{2, 3} {
+		if _, err := getTodoByID(id); err != nil {
+			t.Errorf("todo #%d should be kept: %v", id, err)
+		}
+	}
+}
+
 func TestClearAllTodos(t *testing.T) {
 	setupTestDB(t)
 	defer teardownTestDB()
@@ -452,3 +507,68 @@ func TestClearAllTodos(t *testing.T) {
 		t.Errorf("After clearAllTodos(), count = %v, want 0", count)
 	}
 }
+
+func TestCreateTables_MigratesOldSchema(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	// Recreate the table as the first release shipped it
+	_, err := db.Exec(`DROP TABLE todos`)
+	if err != nil {
+		t.Fatalf("failed to drop table: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE todos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		done INTEGER DEFAULT 0,
+		priority TEXT DEFAULT 'medium',
+		category TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		due_date DATETIME
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create old table: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO todos (title) VALUES ('Old task')`)
+	if err != nil {
+		t.Fatalf("failed to insert old row: %v", err)
+	}
+
+	if err := createTables(); err != nil {
+		t.Fatalf("createTables() error = %v", err)
+	}
+
+	todo, err := getTodoByID(1)
+	if err != nil {
+		t.Fatalf("getTodoByID() after migration error = %v", err)
+	}
+	if todo.Title != "Old task" || todo.Notes != "" {
+		t.Errorf("migrated todo = %+v, want title kept and empty notes", todo)
+	}
+}
+
+func TestCreateTables_BackfillsCompletedAt(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Finished long ago", PriorityMedium, "", "")
+	markTodoAsDone(1)
+
+	// A todo completed before completed_at existed only has history
+	finished := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := db.Exec(`UPDATE todos SET completed_at = NULL`); err != nil {
+		t.Fatalf("failed to reset completed_at: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE history SET changed_at = ? WHERE field = 'done'`, finished); err != nil {
+		t.Fatalf("failed to age history: %v", err)
+	}
+
+	if err := createTables(); err != nil {
+		t.Fatalf("createTables() error = %v", err)
+	}
+
+	todo, _ := getTodoByID(1)
+	if !todo.CompletedAt.Valid || !todo.CompletedAt.Time.Equal(finished) {
+		t.Errorf("CompletedAt = %v, want %v", todo.CompletedAt, finished)
+	}
+}
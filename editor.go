@@ -0,0 +1,199 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runEditor opens path in the user's editor and waits for it to exit.
+// Tests replace it to simulate edits without a terminal.
+var runEditor = func(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// Run through the shell so EDITOR values with arguments ("code --wait")
+	// work as they do elsewhere.
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editText writes content to a temporary file named after pattern, lets the
+// user edit it and returns the saved result.
+func editText(pattern, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := runEditor(path); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(edited), nil
+}
+
+const todoDocumentHelp = `# Edit the fields below, save and quit to apply.
+# Leave category, due or estimate empty to clear them. Everything after the
+# second --- line is the notes, in markdown.`
+
+// todoDocument renders the editable fields of todo as a front-matter
+// document.
+func todoDocument(todo *Todo) string {
+	dueDate := ""
+	if todo.DueDate.Valid {
+		dueDate = todo.DueDate.Time.Format("2006-01-02")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n---\n", todoDocumentHelp)
+	fmt.Fprintf(&b, "title: %s\n", todo.Title)
+	fmt.Fprintf(&b, "priority: %s\n", todo.Priority)
+	fmt.Fprintf(&b, "category: %s\n", todo.Category)
+	fmt.Fprintf(&b, "due: %s\n", dueDate)
+	fmt.Fprintf(&b, "estimate: %s\n", todo.Estimate)
+	fmt.Fprintf(&b, "---\n%s", todo.Notes)
+	if todo.Notes != "" {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// parseTodoDocument reads a document produced by todoDocument back onto a
+// copy of base, validating every field.
+func parseTodoDocument(doc string, base *Todo) (*Todo, error) {
+	todo := *base
+	lines := strings.Split(doc, "\n")
+
+	// Skip comments and blank lines up to the opening ---
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "---" {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return nil, fmt.Errorf("expected --- before the fields, got %q", line)
+		}
+		i++
+	}
+	if i == len(lines) {
+		return nil, fmt.Errorf("missing --- before the fields")
+	}
+	i++
+
+	closed := false
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			closed = true
+			i++
+			break
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected 'field: value', got %q", line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "title":
+			if value == "" {
+				return nil, fmt.Errorf("title can not be empty")
+			}
+			todo.Title = value
+		case "priority":
+			p := Priority(value)
+			if err := currentPriorities().check(p); err != nil {
+				return nil, err
+			}
+			todo.Priority = p
+		case "category":
+			todo.Category = value
+		case "due":
+			if value == "" {
+				todo.DueDate = sql.NullTime{}
+				break
+			}
+			due, err := parseDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date format. Use YYYY-MM-DD")
+			}
+			todo.DueDate = sql.NullTime{Time: due, Valid: true}
+		case "estimate":
+			e, err := parseEstimate(value)
+			if err != nil {
+				return nil, err
+			}
+			todo.Estimate = e.String()
+		default:
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+	}
+	if !closed {
+		return nil, fmt.Errorf("missing --- after the fields")
+	}
+
+	todo.Notes = strings.Trim(strings.Join(lines[i:], "\n"), "\n")
+	return &todo, nil
+}
+
+// stripErrorComment removes the error banner added by a previous failed
+// attempt, so it doesn't pile up across retries.
+func stripErrorComment(doc string) string {
+	for strings.HasPrefix(doc, "# ERROR: ") {
+		_, doc, _ = strings.Cut(doc, "\n")
+	}
+	return doc
+}
+
+// editTodoDocument opens todo in the editor until the result parses, putting
+// the validation error at the top of the file on every retry. Saving the
+// file unchanged after an error gives up with that error.
+func editTodoDocument(todo *Todo) (*Todo, error) {
+	doc := todoDocument(todo)
+	pattern := fmt.Sprintf("todo-%d-*.md", todo.ID)
+
+	for {
+		edited, err := editText(pattern, doc)
+		if err != nil {
+			return nil, fmt.Errorf("editor failed: %w", err)
+		}
+
+		updated, parseErr := parseTodoDocument(stripErrorComment(edited), todo)
+		if parseErr == nil {
+			return updated, nil
+		}
+
+		if edited == doc && strings.HasPrefix(doc, "# ERROR: ") {
+			return nil, parseErr
+		}
+		doc = "# ERROR: " + parseErr.Error() + "\n" + stripErrorComment(edited)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodoDocument_RoundTrip(t *testing.T) {
+	todo := &Todo{
+		ID:       4,
+		Title:    "Buy milk",
+		Priority: PriorityHigh,
+		Category: "home",
+		DueDate:  sql.NullTime{Time: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), Valid: true},
+		Notes:    "# Shop\n- oat milk",
+	}
+
+	got, err := parseTodoDocument(todoDocument(todo), todo)
+	if err != nil {
+		t.Fatalf("parseTodoDocument() error = %v", err)
+	}
+	if got.Title != todo.Title || got.Priority != todo.Priority || got.Category != todo.Category ||
+		got.Notes != todo.Notes || !got.DueDate.Time.Equal(todo.DueDate.Time) {
+		t.Errorf("round trip = %+v, want %+v", got, todo)
+	}
+}
+
+func TestParseTodoDocument(t *testing.T) {
+	base := &Todo{ID: 1, Title: "Task", Priority: PriorityMedium, Category: "work"}
+
+	tests := []struct {
+		name         string
+		doc          string
+		wantErr      string
+		wantTitle    string
+		wantCat      string
+		wantDue      string
+		wantNotes    string
+		wantPriority Priority
+	}{
+		{
+			name:         "all fields",
+			doc:          "---\ntitle: New\npriority: low\ncategory: home\ndue: 2026-01-02\n---\nsome notes\n",
+			wantTitle:    "New",
+			wantCat:      "home",
+			wantDue:      "2026-01-02",
+			wantNotes:    "some notes",
+			wantPriority: PriorityLow,
+		},
+		{
+			name:         "missing fields keep their values and empty clears",
+			doc:          "# comment\n---\ncategory:\n---\n",
+			wantTitle:    "Task",
+			wantCat:      "",
+			wantPriority: PriorityMedium,
+		},
+		{
+			name:    "invalid priority",
+			doc:     "---\npriority: urgent\n---\n",
+			wantErr: "invalid priority",
+		},
+		{
+			name:    "invalid date",
+			doc:     "---\ndue: tomorrow\n---\n",
+			wantErr: "invalid date format",
+		},
+		{
+			name:    "empty title",
+			doc:     "---\ntitle:\n---\n",
+			wantErr: "title can not be empty",
+		},
+		{
+			name:    "unknown field",
+			doc:     "---\nowner: bob\n---\n",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "missing closing delimiter",
+			doc:     "---\ntitle: x\n",
+			wantErr: "missing ---",
+		},
+		{
+			name:    "text before fields",
+			doc:     "hello\n---\n---\n",
+			wantErr: "expected ---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTodoDocument(tt.doc, base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseTodoDocument() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTodoDocument() error = %v", err)
+			}
+
+			if got.Title != tt.wantTitle || got.Category != tt.wantCat || got.Priority != tt.wantPriority || got.Notes != tt.wantNotes {
+				t.Errorf("parseTodoDocument() = %+v", got)
+			}
+			gotDue := ""
+			if got.DueDate.Valid {
+				gotDue = got.DueDate.Time.Format("2006-01-02")
+			}
+			if gotDue != tt.wantDue {
+				t.Errorf("due = %q, want %q", gotDue, tt.wantDue)
+			}
+		})
+	}
+}
+
+func TestEditTodoDocument_RetriesWithError(t *testing.T) {
+	orig := runEditor
+	defer func() { runEditor = orig }()
+
+	var seen []string
+	runEditor = func(path string) error {
+		content, _ := os.ReadFile(path)
+		seen = append(seen, string(content))
+		if len(seen) == 1 {
+			return os.WriteFile(path, []byte("---\npriority: urgent\n---\n"), 0o600)
+		}
+		return os.WriteFile(path, []byte("---\npriority: high\n---\n"), 0o600)
+	}
+
+	todo := &Todo{ID: 1, Title: "Task", Priority: PriorityMedium}
+	got, err := editTodoDocument(todo)
+	if err != nil {
+		t.Fatalf("editTodoDocument() error = %v", err)
+	}
+	if got.Priority != PriorityHigh {
+		t.Errorf("Priority = %v, want %v", got.Priority, PriorityHigh)
+	}
+	if len(seen) != 2 || !strings.HasPrefix(seen[1], "# ERROR: invalid priority") {
+		t.Errorf("second editor session = %q, want error comment at top", seen)
+	}
+}
+
+func TestEditTodoDocument_GivesUpWhenUnchanged(t *testing.T) {
+	orig := runEditor
+	defer func() { runEditor = orig }()
+
+	calls := 0
+	runEditor = func(path string) error {
+		calls++
+		if calls == 1 {
+			return os.WriteFile(path, []byte("---\ndue: soon\n---\n"), 0o600)
+		}
+		return nil
+	}
+
+	_, err := editTodoDocument(&Todo{ID: 1, Title: "Task", Priority: PriorityMedium})
+	if err == nil || !strings.Contains(err.Error(), "invalid date format") {
+		t.Errorf("editTodoDocument() error = %v, want invalid date format", err)
+	}
+	if calls != 2 {
+		t.Errorf("editor opened %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// effort is an estimate of the work a todo takes, either as time or as
+// story points. At most one of the two is set.
+type effort struct {
+	Duration time.Duration
+	Points   float64
+}
+
+// parseEstimate reads a duration such as 2h or 1h30m, or story points such
+// as 3, 3pt or 0.5pts. An empty string means no estimate.
+func parseEstimate(s string) (effort, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return effort{}, nil
+	}
+
+	number := s
+	for _, unit := range []string{"pts", "pt"} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			number = strings.TrimSpace(n)
+			break
+		}
+	}
+	if points, err := strconv.ParseFloat(number, 64); err == nil {
+		if !(points > 0) || math.IsInf(points, 0) {
+			return effort{}, fmt.Errorf("invalid estimate %q. Use a positive number of points", s)
+		}
+		return effort{Points: points}, nil
+	}
+
+	d, err := parseDuration(s)
+	if err != nil || d < time.Minute {
+		return effort{}, fmt.Errorf("invalid estimate %q. Use a duration like 2h or 1h30m, or story points like 3pt", s)
+	}
+	return effort{Duration: d.Round(time.Minute)}, nil
+}
+
+// estimateOf reads the stored estimate of todo. Estimates are validated
+// when set, so a value that doesn't parse counts as none.
+func estimateOf(todo Todo) effort {
+	e, _ := parseEstimate(todo.Estimate)
+	return e
+}
+
+func (e effort) IsZero() bool {
+	return e.Duration == 0 && e.Points == 0
+}
+
+// String is the stored form: 2h30m or 3pt.
+func (e effort) String() string {
+	switch {
+	case e.Points > 0:
+		return strconv.FormatFloat(e.Points, 'f', -1, 64) + "pt"
+	case e.Duration > 0:
+		return shortDuration(e.Duration)
+	}
+	return ""
+}
+
+// Display renders time the way tracked time is shown, so the two compare
+// at a glance, and points as "3 pts".
+func (e effort) Display() string {
+	var parts []string
+	if e.Duration > 0 {
+		parts = append(parts, formatSpent(e.Duration))
+	}
+	if e.Points > 0 {
+		unit := "pts"
+		if e.Points == 1 {
+			unit = "pt"
+		}
+		parts = append(parts, strconv.FormatFloat(e.Points, 'f', -1, 64)+" "+unit)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// add sums estimates, keeping time and points apart since they don't
+// convert into each other.
+func (e effort) add(other effort) effort {
+	return effort{Duration: e.Duration + other.Duration, Points: e.Points + other.Points}
+}
+
+// printEstimateTotals closes a list with the estimated work left per
+// category. Lists without estimated pending todos print nothing.
+func printEstimateTotals(todos []Todo) {
+	byCategory := map[string]effort{}
+	var total effort
+	for _, todo := range todos {
+		e := estimateOf(todo)
+		if todo.Done || e.IsZero() {
+			continue
+		}
+		byCategory[todo.Category] = byCategory[todo.Category].add(e)
+		total = total.add(e)
+	}
+	if total.IsZero() {
+		return
+	}
+
+	if len(byCategory) == 1 {
+		fmt.Printf("Estimated pending work: %s\n", total.Display())
+		return
+	}
+
+	categories := make([]string, 0, len(byCategory))
+	width := len("Total")
+	for category := range byCategory {
+		categories = append(categories, category)
+		width = max(width, displayWidth(category), len("(none)"))
+	}
+	sort.Strings(categories)
+
+	fmt.Println("Estimated pending work:")
+	for _, category := range categories {
+		name := category
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("  %s  %s\n", padWidth(name, width), byCategory[category].Display())
+	}
+	fmt.Printf("  %s  %s\n", colorize(Bold, padWidth("Total", width)), total.Display())
+}
+
+func cmdEstimate(id int, value string) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+
+	e, err := parseEstimate(value)
+	if err != nil {
+		return err
+	}
+	if e.IsZero() && todo.Estimate == "" {
+		fmt.Printf("Todo #%d has no estimate\n", id)
+		return nil
+	}
+
+	if err := setTodoEstimate(id, e); err != nil {
+		return err
+	}
+	if e.IsZero() {
+		fmt.Printf("%s Cleared the estimate of #%d\n", colorize(Green, "✓"), id)
+		return nil
+	}
+	fmt.Printf("%s Estimated #%d at %s\n", colorize(Green, "✓"), id, e.Display())
+	return nil
+}
+
+// estimateResult pairs a completed todo's estimate with the time tracked
+// on it.
+type estimateResult struct {
+	Todo     Todo
+	Estimate effort
+	Actual   time.Duration
+}
+
+// estimateTally adds up estimates against tracked time, separately for
+// time estimates and story points.
+type estimateTally struct {
+	Estimated time.Duration
+	Timed     time.Duration
+	Points    float64
+	Pointed   time.Duration
+}
+
+func (t *estimateTally) add(r estimateResult) {
+	if r.Estimate.Points > 0 {
+		t.Points += r.Estimate.Points
+		t.Pointed += r.Actual
+		return
+	}
+	t.Estimated += r.Estimate.Duration
+	t.Timed += r.Actual
+}
+
+// summary describes how far off the estimates were: the ratio of tracked
+// to estimated time, and the time one story point took.
+func (t estimateTally) summary() []string {
+	var lines []string
+	if t.Estimated > 0 {
+		lines = append(lines, fmt.Sprintf("%s the estimate (%s estimated, %s tracked)",
+			formatRatio(float64(t.Timed)/float64(t.Estimated)), formatSpent(t.Estimated), formatSpent(t.Timed)))
+	}
+	if t.Points > 0 {
+		perPoint := time.Duration(float64(t.Pointed) / t.Points)
+		lines = append(lines, fmt.Sprintf("%s per story point (%s over %s)",
+			formatSpent(perPoint), formatSpent(t.Pointed), effort{Points: t.Points}.Display()))
+	}
+	return lines
+}
+
+func formatRatio(r float64) string {
+	return fmt.Sprintf("%.2f×", r)
+}
+
+// estimateResults collects the completed todos with an estimate, with the
+// time tracked on each.
+func estimateResults(category string, since time.Time) ([]estimateResult, error) {
+	todos, err := getTodos(todoFilter{ShowDone: true, Snoozed: true, Category: category, Project: activeProjectID()})
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() {
+		todos = completedAfter(todos, since)
+	}
+
+	now := clock()
+	var results []estimateResult
+	for _, todo := range todos {
+		e := estimateOf(todo)
+		if e.IsZero() {
+			continue
+		}
+		actual, err := timeSpent(todo.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, estimateResult{Todo: todo, Estimate: e, Actual: actual})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Todo.CompletedAt.Time.Before(results[j].Todo.CompletedAt.Time)
+	})
+	return results, nil
+}
+
+// cmdEstimates compares the estimates of completed todos with the time
+// tracked on them, per todo, per category and overall. Todos without
+// tracked time are listed but left out of the totals.
+func cmdEstimates(category, since string) error {
+	var from time.Time
+	if since != "" {
+		var err error
+		from, err = parseSince(since)
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := estimateResults(category, from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nEstimates vs. tracked time:")
+	fmt.Println("---------------------------------------")
+	if len(results) == 0 {
+		fmt.Println("No completed todos with an estimate")
+		return nil
+	}
+
+	table := NewTable([]string{"ID", "Title", "Category", "Completed", "Estimate", "Tracked", "Ratio"})
+	table.SetFlexible("Title", "Category")
+	table.SetAlign(AlignRight, "ID", "Estimate", "Tracked", "Ratio")
+
+	var total estimateTally
+	byCategory := map[string]*estimateTally{}
+	untracked := 0
+	for _, r := range results {
+		tracked, ratio := formatSpent(r.Actual), ""
+		switch {
+		case r.Actual == 0:
+			tracked = "-"
+			untracked++
+		case r.Estimate.Points > 0:
+			ratio = formatSpent(time.Duration(float64(r.Actual)/r.Estimate.Points)) + "/pt"
+		default:
+			rt := float64(r.Actual) / float64(r.Estimate.Duration)
+			ratio = colorize(ratioColor(rt), formatRatio(rt))
+		}
+
+		table.AddRow([]string{
+			fmt.Sprintf("%d", r.Todo.ID),
+			r.Todo.Title,
+			r.Todo.Category,
+			r.Todo.CompletedAt.Time.Local().Format(cfg.Get("date.format")),
+			r.Estimate.Display(),
+			tracked,
+			ratio,
+		})
+
+		if r.Actual == 0 {
+			continue
+		}
+		total.add(r)
+		if byCategory[r.Todo.Category] == nil {
+			byCategory[r.Todo.Category] = &estimateTally{}
+		}
+		byCategory[r.Todo.Category].add(r)
+	}
+	table.Print()
+
+	if len(byCategory) > 1 {
+		categories := make([]string, 0, len(byCategory))
+		width := len("(none)")
+		for c := range byCategory {
+			categories = append(categories, c)
+			width = max(width, displayWidth(c))
+		}
+		sort.Strings(categories)
+
+		fmt.Println("\nBy category:")
+		for _, c := range categories {
+			name := c
+			if name == "" {
+				name = "(none)"
+			}
+			for _, line := range byCategory[c].summary() {
+				fmt.Printf("  %s  %s\n", padWidth(name, width), line)
+			}
+		}
+	}
+
+	if lines := total.summary(); len(lines) > 0 {
+		fmt.Println("\nOverall:")
+		for _, line := range lines {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if untracked > 0 {
+		fmt.Printf("\n%d todo(s) without tracked time are left out of the totals\n", untracked)
+	}
+	fmt.Println()
+	return nil
+}
+
+// ratioColor flags todos that took much longer than estimated in the
+// overdue color, and those done well within the estimate in the done color.
+func ratioColor(r float64) Color {
+	switch {
+	case r > 1.5:
+		return roleColor("overdue")
+	case r <= 1:
+		return roleColor("done")
+	}
+	return Reset
+}
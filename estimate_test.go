@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		input   string
+		stored  string
+		display string
+		wantErr bool
+	}{
+		{input: "", stored: "", display: ""},
+		{input: "2h", stored: "2h", display: "2h 00m"},
+		{input: "1h30m", stored: "1h30m", display: "1h 30m"},
+		{input: "45m", stored: "45m", display: "45m"},
+		{input: "26h", stored: "1d2h", display: "26h 00m"},
+		{input: "2d", stored: "2d", display: "48h 00m"},
+		{input: "3", stored: "3pt", display: "3 pts"},
+		{input: "1pt", stored: "1pt", display: "1 pt"},
+		{input: "0.5 pts", stored: "0.5pt", display: "0.5 pts"},
+		{input: "0", wantErr: true},
+		{input: "-2", wantErr: true},
+		{input: "30s", wantErr: true},
+		{input: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		e, err := parseEstimate(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEstimate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if e.String() != tt.stored || e.Display() != tt.display {
+			t.Errorf("parseEstimate(%q) = %q / %q, want %q / %q", tt.input, e.String(), e.Display(), tt.stored, tt.display)
+		}
+
+		// The stored form reads back as the same estimate
+		if again, err := parseEstimate(e.String()); err != nil || again != e {
+			t.Errorf("parseEstimate(%q) = %+v, %v, want %+v", e.String(), again, err, e)
+		}
+	}
+}
+
+func TestCmdEstimate(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	captureOutput(func() {
+		if err := cmdAdd(addOptions{Title: "Write report", Priority: PriorityHigh, Category: "work", Estimate: "2h"}); err != nil {
+			t.Fatalf("cmdAdd() with an estimate error = %v", err)
+		}
+	})
+	if todo, _ := getTodoByID(1); todo.Estimate != "2h" {
+		t.Errorf("estimate after add = %q, want 2h", todo.Estimate)
+	}
+	if err := cmdAdd(addOptions{Title: "Bad", Priority: PriorityHigh, Estimate: "lots"}); err == nil {
+		t.Errorf("cmdAdd() with an invalid estimate should fail")
+	}
+
+	// Adding with an estimate is a single step to undo
+	if _, err := undoJournal(1); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if _, err := getTodoByID(1); err == nil {
+		t.Errorf("undo should remove the todo added with an estimate")
+	}
+
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+	out := stripAnsi(captureOutput(func() { cmdEstimate(2, "3") }))
+	if !strings.Contains(out, "Estimated #2 at 3 pts") {
+		t.Errorf("cmdEstimate() output = %q", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdShow(2) }))
+	if !strings.Contains(out, "Estimate:  3 pts") {
+		t.Errorf("cmdShow() missing the estimate:\n%s", out)
+	}
+
+	history, _ := getTodoHistory(2)
+	if got := stripAnsi(describeChange(history[len(history)-1])); !strings.Contains(got, "estimate: (none) → 3 pts") {
+		t.Errorf("history entry = %q", got)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdEstimate(2, "") }))
+	if !strings.Contains(out, "Cleared the estimate of #2") {
+		t.Errorf("cmdEstimate() clearing = %q", out)
+	}
+	out = captureOutput(func() { cmdEstimate(2, "") })
+	if !strings.Contains(out, "has no estimate") {
+		t.Errorf("cmdEstimate() clearing again = %q", out)
+	}
+}
+
+func TestListEstimateTotals(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+	insertTestTodo(t, "Groceries", PriorityLow, "home", "")
+	insertTestTodo(t, "Done already", PriorityLow, "home", "")
+	setTodoEstimate(1, effort{Duration: 2 * time.Hour})
+	setTodoEstimate(2, effort{Points: 3})
+	setTodoEstimate(3, effort{Duration: 30 * time.Minute})
+	setTodoEstimate(4, effort{Duration: 8 * time.Hour})
+	markTodoAsDone(4)
+
+	out := stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	for _, want := range []string{"Estimated pending work:", "home    30m", "work    2h 00m · 3 pts", "Total   2h 30m · 3 pts"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("list footer missing %q:\n%s", want, out)
+		}
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{Category: "home"}) }))
+	if !strings.Contains(out, "Estimated pending work: 30m\n") {
+		t.Errorf("list footer for one category:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{ShowDone: true}) }))
+	if strings.Contains(out, "Estimated") {
+		t.Errorf("a list of done todos should have no estimate footer:\n%s", out)
+	}
+}
+
+func TestCmdEstimates(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	setClock(t, time.Date(2026, 10, 21, 18, 0, 0, 0, time.Local))
+
+	out := captureOutput(func() { cmdEstimates("", "") })
+	if !strings.Contains(out, "No completed todos with an estimate") {
+		t.Errorf("cmdEstimates() on an empty list = %q", out)
+	}
+
+	todos := []struct {
+		title    string
+		category string
+		estimate effort
+		tracked  time.Duration
+	}{
+		{title: "Report", category: "work", estimate: effort{Duration: 2 * time.Hour}, tracked: 3 * time.Hour},
+		{title: "Slides", category: "work", estimate: effort{Duration: 2 * time.Hour}, tracked: time.Hour},
+		{title: "Bug fix", category: "work", estimate: effort{Points: 2}, tracked: 5 * time.Hour},
+		{title: "Groceries", category: "home", estimate: effort{Duration: time.Hour}, tracked: 90 * time.Minute},
+		{title: "Untracked", category: "home", estimate: effort{Duration: time.Hour}},
+		{title: "No estimate", category: "home", tracked: time.Hour},
+	}
+	for i, todo := range todos {
+		id := int(insertTestTodo(t, todo.title, PriorityMedium, todo.category, ""))
+		setTodoEstimate(id, todo.estimate)
+		if todo.tracked > 0 {
+			logTime(id, clock().Add(-time.Duration(i+1)*24*time.Hour), todo.tracked, entryManual)
+		}
+		markTodoAsDone(id)
+	}
+	insertTestTodo(t, "Pending", PriorityMedium, "work", "")
+	setTodoEstimate(7, effort{Duration: time.Hour})
+
+	out = stripAnsi(captureOutput(func() {
+		if err := cmdEstimates("", ""); err != nil {
+			t.Fatalf("cmdEstimates() error = %v", err)
+		}
+	}))
+	for _, want := range []string{
+		"1.50×", "0.50×", "2h 30m/pt",
+		"home    1.50× the estimate (1h 00m estimated, 1h 30m tracked)",
+		"work    1.00× the estimate (4h 00m estimated, 4h 00m tracked)",
+		"work    2h 30m per story point (5h 00m over 2 pts)",
+		"Overall:\n  1.10× the estimate (5h 00m estimated, 5h 30m tracked)",
+		"1 todo(s) without tracked time",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdEstimates() missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"No estimate", "Pending"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("cmdEstimates() should leave out %q:\n%s", unwanted, out)
+		}
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdEstimates("home", "") }))
+	if strings.Contains(out, "Report") || strings.Contains(out, "By category") {
+		t.Errorf("cmdEstimates(home):\n%s", out)
+	}
+}
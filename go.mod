@@ -2,4 +2,4 @@ module todo
 
 go 1.25.5
 
-require github.com/mattn/go-sqlite3 v1.14.32 // indirect
+require github.com/mattn/go-sqlite3 v1.14.32
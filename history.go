@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"os/user"
+	"time"
+)
+
+// History actions. Unlike the journal, history rows are never rewritten by
+// undo or redo; replays are appended as new changes.
+const (
+	actionCreated = "created"
+	actionUpdated = "updated"
+	actionDeleted = "deleted"
+)
+
+type HistoryEntry struct {
+	ID        int
+	TodoID    int
+	Action    string
+	Field     string
+	OldValue  string
+	NewValue  string
+	Actor     string
+	ChangedAt time.Time
+}
+
+// currentActor names whoever is making a change: TODO_ACTOR if set,
+// otherwise the login name.
+func currentActor() string {
+	if actor := os.Getenv("TODO_ACTOR"); actor != "" {
+		return actor
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "unknown"
+}
+
+func createHistoryTable() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		field TEXT DEFAULT '',
+		old_value TEXT DEFAULT '',
+		new_value TEXT DEFAULT '',
+		actor TEXT DEFAULT '',
+		changed_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+func recordHistory(tx *sql.Tx, todoID int, action, field, oldValue, newValue string) error {
+	_, err := tx.Exec(
+		`INSERT INTO history (todo_id, action, field, old_value, new_value, actor, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		todoID, action, field, oldValue, newValue, currentActor(), time.Now().UTC(),
+	)
+	return err
+}
+
+func getTodoHistory(id int) ([]HistoryEntry, error) {
+	return queryHistory(`WHERE todo_id = ?`, id)
+}
+
+func getHistorySince(since time.Time) ([]HistoryEntry, error) {
+	return queryHistory(`WHERE changed_at >= ?`, since.UTC())
+}
+
+func queryHistory(where string, args ...any) ([]HistoryEntry, error) {
+	rows, err := db.Query(
+		`SELECT id, todo_id, action, field, old_value, new_value, actor, changed_at
+		FROM history `+where+` ORDER BY changed_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var h HistoryEntry
+		err := rows.Scan(&h.ID, &h.TodoID, &h.Action, &h.Field, &h.OldValue, &h.NewValue, &h.Actor, &h.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, h)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordsChanges(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	t.Setenv("TODO_ACTOR", "alice")
+
+	id := int(insertTestTodo(t, "Buy milk", PriorityMedium, "", "2025-06-01"))
+	updateTodo(id, "", "", "", "2025-06-15")
+	markTodoAsDone(id)
+	deleteTodo(id)
+
+	entries, err := getTodoHistory(id)
+	if err != nil {
+		t.Fatalf("getTodoHistory() error = %v", err)
+	}
+
+	want := []struct {
+		action, field, oldValue, newValue string
+	}{
+		{actionCreated, "", "", "Buy milk"},
+		{actionUpdated, "due_date", "2025-06-01", "2025-06-15"},
+		{actionUpdated, "done", "0", "1"},
+		{actionDeleted, "", "Buy milk", ""},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("getTodoHistory() returned %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+
+	for i, w := range want {
+		h := entries[i]
+		if h.Action != w.action || h.Field != w.field || h.OldValue != w.oldValue || h.NewValue != w.newValue {
+			t.Errorf("entry[%d] = %s %s %q→%q, want %s %s %q→%q",
+				i, h.Action, h.Field, h.OldValue, h.NewValue, w.action, w.field, w.oldValue, w.newValue)
+		}
+		if h.Actor != "alice" {
+			t.Errorf("entry[%d] actor = %q, want %q", i, h.Actor, "alice")
+		}
+	}
+}
+
+func TestHistory_UnchangedFieldsNotRecorded(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityHigh, "", ""))
+	updateTodo(id, "Task", PriorityHigh, "", "")
+	markTodoAsUndone(id)
+
+	entries, _ := getTodoHistory(id)
+	if len(entries) != 1 {
+		t.Errorf("getTodoHistory() returned %d entries, want only the creation", len(entries))
+	}
+}
+
+func TestHistory_UndoIsRecorded(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "", ""))
+	updateTodo(id, "", PriorityHigh, "", "")
+	undoJournal(1)
+
+	entries, _ := getTodoHistory(id)
+	last := entries[len(entries)-1]
+	if last.Field != "priority" || last.OldValue != "high" || last.NewValue != "medium" {
+		t.Errorf("last entry = %+v, want priority high→medium", last)
+	}
+}
+
+func TestGetHistorySince(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityMedium, "", "")
+	insertTestTodo(t, "Task 2", PriorityMedium, "", "")
+
+	entries, err := getHistorySince(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("getHistorySince() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("getHistorySince(1h ago) returned %d entries, want 2", len(entries))
+	}
+
+	entries, _ = getHistorySince(time.Now().Add(time.Hour))
+	if len(entries) != 0 {
+		t.Errorf("getHistorySince(future) returned %d entries, want 0", len(entries))
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Journal operations. Every mutation is stored as one or more entries sharing
+// a batch number, so a single command can be undone or redone as a unit.
+const (
+	opInsert = "insert"
+	opDelete = "delete"
+	opSet    = "set"
+)
+
+type journalEntry struct {
+	ID       int64
+	Batch    int64
+	Command  string
+	Op       string
+	TodoID   int
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+// journalBatch collects the entries written by one command inside its
+// transaction.
+type journalBatch struct {
+	tx      *sql.Tx
+	id      int64
+	command string
+	count   int
+}
+
+// journalStep is the outcome of undoing or redoing one batch.
+type journalStep struct {
+	Command  string
+	Messages []string
+}
+
+type todoField struct {
+	Column string
+	Label  string
+	Value  string
+
+	// Derived fields follow from another field, like completed_at from
+	// done. They are journaled so undo restores them, but left out of the
+	// history and of undo/redo messages.
+	Derived bool
+}
+
+// todoFields lists the journaled columns of a todo with their values
+// rendered as strings.
+func todoFields(todo *Todo) []todoField {
+	done := "0"
+	if todo.Done {
+		done = "1"
+	}
+
+	dueDate := ""
+	if todo.DueDate.Valid {
+		dueDate = todo.DueDate.Time.Format("2006-01-02")
+	}
+
+	completedAt := ""
+	if todo.CompletedAt.Valid {
+		completedAt = todo.CompletedAt.Time.UTC().Format(time.RFC3339)
+	}
+
+	waitUntil := ""
+	if todo.WaitUntil.Valid {
+		waitUntil = todo.WaitUntil.Time.UTC().Format(time.RFC3339)
+	}
+
+	return []todoField{
+		{Column: "title", Label: "title", Value: todo.Title},
+		{Column: "priority", Label: "priority", Value: string(todo.Priority)},
+		{Column: "category", Label: "category", Value: todo.Category},
+		{Column: "due_date", Label: "due date", Value: dueDate},
+		{Column: "done", Label: "status", Value: done},
+		{Column: "notes", Label: "notes", Value: todo.Notes},
+		{Column: "completed_at", Label: "completed", Value: completedAt, Derived: true},
+		{Column: "state", Label: "state", Value: todo.State},
+		{Column: "wait_until", Label: "snoozed until", Value: waitUntil},
+		{Column: "estimate", Label: "estimate", Value: todo.Estimate},
+		{Column: "project_id", Label: "project", Value: strconv.Itoa(todo.ProjectID)},
+	}
+}
+
+func fieldLabel(column string) (string, bool) {
+	f, ok := lookupField(column)
+	return f.Label, ok
+}
+
+func lookupField(column string) (todoField, bool) {
+	for _, f := range todoFields(&Todo{}) {
+		if f.Column == column {
+			return f, true
+		}
+	}
+	return todoField{}, false
+}
+
+// fieldValue converts a journaled string back into a value for the column.
+func fieldValue(column, value string) any {
+	switch {
+	case (column == "due_date" || column == "completed_at" || column == "wait_until") && value == "":
+		return nil
+	case column == "completed_at" || column == "wait_until":
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return value
+}
+
+func createJournalTable() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS journal (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch INTEGER NOT NULL,
+		command TEXT NOT NULL,
+		op TEXT NOT NULL,
+		todo_id INTEGER NOT NULL,
+		field TEXT DEFAULT '',
+		old_value TEXT DEFAULT '',
+		new_value TEXT DEFAULT '',
+		undone INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// withJournal runs fn in a transaction together with a fresh journal batch.
+// If fn records anything, previously undone batches are discarded since they
+// can no longer be redone on top of the new state.
+func withJournal(command string, fn func(b *journalBatch) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	b := &journalBatch{tx: tx, command: command}
+	err = tx.QueryRow(`SELECT COALESCE(MAX(batch), 0) + 1 FROM journal`).Scan(&b.id)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(b); err != nil {
+		return err
+	}
+
+	if b.count > 0 {
+		_, err = tx.Exec(`DELETE FROM journal WHERE undone = 1`)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (b *journalBatch) record(entry journalEntry) error {
+	_, err := b.tx.Exec(
+		`INSERT INTO journal (batch, command, op, todo_id, field, old_value, new_value)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		b.id, b.command, entry.Op, entry.TodoID, entry.Field, entry.OldValue, entry.NewValue,
+	)
+	if err != nil {
+		return err
+	}
+
+	b.count++
+	return nil
+}
+
+func (b *journalBatch) insert(todo *Todo) error {
+	snapshot, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	if err := recordHistory(b.tx, todo.ID, actionCreated, "", "", todo.Title); err != nil {
+		return err
+	}
+
+	return b.record(journalEntry{Op: opInsert, TodoID: todo.ID, NewValue: string(snapshot)})
+}
+
+func (b *journalBatch) delete(todo *Todo) error {
+	snapshot, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	if err := recordHistory(b.tx, todo.ID, actionDeleted, "", todo.Title, ""); err != nil {
+		return err
+	}
+
+	return b.record(journalEntry{Op: opDelete, TodoID: todo.ID, OldValue: string(snapshot)})
+}
+
+// changes records one entry per field that differs between before and the
+// current state of the todo.
+func (b *journalBatch) changes(before *Todo) error {
+	after, err := fetchTodo(b.tx, before.ID)
+	if err != nil {
+		return err
+	}
+
+	oldFields := todoFields(before)
+	newFields := todoFields(after)
+	for i, f := range oldFields {
+		if f.Value == newFields[i].Value {
+			continue
+		}
+
+		if !f.Derived {
+			err := recordHistory(b.tx, before.ID, actionUpdated, f.Column, f.Value, newFields[i].Value)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := b.record(journalEntry{
+			Op:       opSet,
+			TodoID:   before.ID,
+			Field:    f.Column,
+			OldValue: f.Value,
+			NewValue: newFields[i].Value,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadBatch(tx *sql.Tx, batch int64, order string) ([]journalEntry, error) {
+	rows, err := tx.Query(
+		`SELECT id, batch, command, op, todo_id, field, old_value, new_value
+		FROM journal WHERE batch = ? ORDER BY id `+order, batch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []journalEntry
+	for rows.Next() {
+		var e journalEntry
+		err := rows.Scan(&e.ID, &e.Batch, &e.Command, &e.Op, &e.TodoID, &e.Field, &e.OldValue, &e.NewValue)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// undoJournal reverts up to steps of the most recent batches.
+func undoJournal(steps int) ([]journalStep, error) {
+	return replayJournal(steps, true)
+}
+
+// redoJournal re-applies up to steps of the earliest undone batches.
+func redoJournal(steps int) ([]journalStep, error) {
+	return replayJournal(steps, false)
+}
+
+func replayJournal(steps int, undo bool) ([]journalStep, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pick := `SELECT MIN(batch) FROM journal WHERE undone = 1`
+	order := "ASC"
+	mark := 0
+	if undo {
+		pick = `SELECT MAX(batch) FROM journal WHERE undone = 0`
+		order = "DESC"
+		mark = 1
+	}
+
+	var result []journalStep
+	for i := 0; i < steps; i++ {
+		var batch sql.NullInt64
+		if err := tx.QueryRow(pick).Scan(&batch); err != nil {
+			return nil, err
+		}
+		if !batch.Valid {
+			break
+		}
+
+		entries, err := loadBatch(tx, batch.Int64, order)
+		if err != nil {
+			return nil, err
+		}
+
+		step := journalStep{}
+		for _, e := range entries {
+			step.Command = e.Command
+			msg, err := applyEntry(tx, e, undo)
+			if err != nil {
+				return nil, err
+			}
+			if msg != "" {
+				step.Messages = append(step.Messages, msg)
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE journal SET undone = ? WHERE batch = ?`, mark, batch.Int64)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, step)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// applyEntry applies the inverse of e when undo is set, or e itself
+// otherwise, and describes what it did.
+func applyEntry(tx *sql.Tx, e journalEntry, undo bool) (string, error) {
+	switch e.Op {
+	case opSet:
+		field, ok := lookupField(e.Field)
+		if !ok {
+			return "", fmt.Errorf("journal entry #%d has unknown field %q", e.ID, e.Field)
+		}
+		label := field.Label
+
+		value, previous := e.NewValue, e.OldValue
+		verb := "Set"
+		if undo {
+			value, previous = e.OldValue, e.NewValue
+			verb = "Restored"
+		}
+
+		query := "UPDATE todos SET " + e.Field + " = ? WHERE id = ?"
+		if _, err := tx.Exec(query, fieldValue(e.Field, value), e.TodoID); err != nil {
+			return "", err
+		}
+		if field.Derived {
+			return "", nil
+		}
+		if err := recordHistory(tx, e.TodoID, actionUpdated, e.Field, previous, value); err != nil {
+			return "", err
+		}
+
+		switch {
+		case e.Field == "done" && value == "1":
+			return fmt.Sprintf("Marked #%d as done", e.TodoID), nil
+		case e.Field == "done":
+			return fmt.Sprintf("Marked #%d as not done", e.TodoID), nil
+		case value == "":
+			return fmt.Sprintf("Cleared %s of #%d", label, e.TodoID), nil
+		case e.Field == "notes":
+			return fmt.Sprintf("%s notes of #%d", verb, e.TodoID), nil
+		}
+		return fmt.Sprintf("%s %s of #%d to '%s'", verb, label, e.TodoID, value), nil
+
+	case opInsert, opDelete:
+		snapshot := e.NewValue
+		if e.Op == opDelete {
+			snapshot = e.OldValue
+		}
+
+		var todo Todo
+		if err := json.Unmarshal([]byte(snapshot), &todo); err != nil {
+			return "", err
+		}
+
+		// Undoing an insert and redoing a delete both remove the row.
+		if (e.Op == opInsert) == undo {
+			if _, err := tx.Exec("DELETE FROM todos WHERE id = ?", todo.ID); err != nil {
+				return "", err
+			}
+			if err := recordHistory(tx, todo.ID, actionDeleted, "", todo.Title, ""); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Removed #%d '%s'", todo.ID, todo.Title), nil
+		}
+
+		if err := restoreTodo(tx, &todo); err != nil {
+			return "", err
+		}
+		if err := recordHistory(tx, todo.ID, actionCreated, "", "", todo.Title); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Restored #%d '%s'", todo.ID, todo.Title), nil
+	}
+
+	return "", fmt.Errorf("journal entry #%d has unknown operation %q", e.ID, e.Op)
+}
+
+// restoreTodo re-inserts a todo with its original ID and timestamps.
+func restoreTodo(tx *sql.Tx, todo *Todo) error {
+	done := 0
+	if todo.Done {
+		done = 1
+	}
+
+	_, err := tx.Exec(
+		`INSERT INTO todos (`+todoColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		todo.ID, todo.Title, done, string(todo.Priority), todo.Category, todo.CreatedAt, todo.DueDate, todo.Notes, todo.CompletedAt, todo.State, todo.WaitUntil, todo.Estimate, todo.ProjectID,
+	)
+	return err
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUndoRedo_Edit(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Buy milk", PriorityMedium, "", ""))
+	if err := updateTodo(id, "Buy oat milk", PriorityHigh, "", ""); err != nil {
+		t.Fatalf("updateTodo() error = %v", err)
+	}
+
+	steps, err := undoJournal(1)
+	if err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if len(steps) != 1 || len(steps[0].Messages) != 2 {
+		t.Fatalf("undoJournal() = %+v, want 1 step with 2 messages", steps)
+	}
+	if !strings.Contains(strings.Join(steps[0].Messages, "\n"), "Restored title of #1 to 'Buy milk'") {
+		t.Errorf("undoJournal() messages = %v, want title restore", steps[0].Messages)
+	}
+
+	todo, _ := getTodoByID(id)
+	if todo.Title != "Buy milk" || todo.Priority != PriorityMedium {
+		t.Errorf("after undo got %q/%v, want %q/%v", todo.Title, todo.Priority, "Buy milk", PriorityMedium)
+	}
+
+	if _, err := redoJournal(1); err != nil {
+		t.Fatalf("redoJournal() error = %v", err)
+	}
+
+	todo, _ = getTodoByID(id)
+	if todo.Title != "Buy oat milk" || todo.Priority != PriorityHigh {
+		t.Errorf("after redo got %q/%v, want %q/%v", todo.Title, todo.Priority, "Buy oat milk", PriorityHigh)
+	}
+}
+
+func TestUndoRedo_DoneAndDueDate(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Task", PriorityMedium, "", ""))
+	updateTodo(id, "", "", "", "2025-06-15")
+	markTodoAsDone(id)
+
+	if _, err := undoJournal(2); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+
+	todo, _ := getTodoByID(id)
+	if todo.Done {
+		t.Errorf("Done = true after undo, want false")
+	}
+	if todo.DueDate.Valid {
+		t.Errorf("DueDate = %v after undo, want NULL", todo.DueDate.Time)
+	}
+	if todo.CompletedAt.Valid {
+		t.Errorf("CompletedAt = %v after undo, want NULL", todo.CompletedAt.Time)
+	}
+
+	steps, err := redoJournal(2)
+	if err != nil {
+		t.Fatalf("redoJournal() error = %v", err)
+	}
+	if len(steps) != 2 || len(steps[1].Messages) != 1 {
+		t.Errorf("redoJournal() steps = %+v, want one message for done", steps)
+	}
+
+	todo, _ = getTodoByID(id)
+	if !todo.Done || !todo.CompletedAt.Valid {
+		t.Errorf("after redo Done = %v, CompletedAt = %v, want done with a completion time", todo.Done, todo.CompletedAt)
+	}
+}
+
+func TestUndoRedo_DeleteAndClear(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityMedium, "", "")
+	insertTestTodo(t, "Task 2", PriorityHigh, "work", "2025-12-31")
+	insertTestTodo(t, "Task 3", PriorityLow, "", "")
+	markTodoAsDone(1)
+	markTodoAsDone(2)
+
+	if err := deleteTodo(3); err != nil {
+		t.Fatalf("deleteTodo() error = %v", err)
+	}
+	if err := clearCompletedTodos(); err != nil {
+		t.Fatalf("clearCompletedTodos() error = %v", err)
+	}
+
+	steps, err := undoJournal(2)
+	if err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("undoJournal() returned %d steps, want 2", len(steps))
+	}
+
+	count, _ := countAllTodos()
+	if count != 3 {
+		t.Errorf("count after undo = %d, want 3", count)
+	}
+
+	todo, err := getTodoByID(2)
+	if err != nil {
+		t.Fatalf("getTodoByID() error = %v", err)
+	}
+	if !todo.Done || todo.Category != "work" || todo.DueDate.Time.Format("2006-01-02") != "2025-12-31" {
+		t.Errorf("restored todo = %+v, want original fields", todo)
+	}
+
+	if _, err := redoJournal(1); err != nil {
+		t.Fatalf("redoJournal() error = %v", err)
+	}
+	if _, err := getTodoByID(3); err == nil {
+		t.Error("todo #3 should be deleted again after redo")
+	}
+}
+
+func TestUndoRedo_NewCommandDiscardsRedo(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Task 1", PriorityMedium, "", "")
+	undoJournal(1)
+	insertTestTodo(t, "Task 2", PriorityMedium, "", "")
+
+	steps, err := redoJournal(1)
+	if err != nil {
+		t.Fatalf("redoJournal() error = %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("redoJournal() = %+v, want nothing to redo", steps)
+	}
+}
+
+func TestUndoJournal_Empty(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	steps, err := undoJournal(3)
+	if err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("undoJournal() = %+v, want no steps", steps)
+	}
+}
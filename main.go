@@ -5,11 +5,36 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 func main() {
+	// load settings before anything that depends on them
+	loaded, err := loadConfig(defaultConfigPath())
+	if err != nil {
+		fmt.Println("Error reading config: ", err)
+		os.Exit(1)
+	}
+	cfg = loaded
+
+	// --color applies to every command, given before or right after its name
+	colorMode := cfg.Get("color.mode")
+	os.Args, colorMode = extractGlobalFlag(os.Args, "color", colorMode)
+
+	colorEnabled, err = useColor(colorMode, os.Getenv("NO_COLOR") != "", isTerminal(os.Stdout))
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	activeTheme, err = loadTheme(cfg.Get("color.theme"), cfg.themeOverrides())
+	if err != nil {
+		fmt.Println("Error reading config: ", err)
+		os.Exit(1)
+	}
+
 	// initialiize the database
-	err := initDB()
+	err = initDB(cfg.Get("db.path"))
 
 	if err != nil {
 		fmt.Println("Error initializing database: ", err)
@@ -17,6 +42,23 @@ func main() {
 	}
 	defer db.Close()
 
+	// --project is global too; its settings apply on top of the config
+	// file, so it is read before any flag defaults
+	projectFlag := cfg.Get("defaults.project")
+	os.Args, projectFlag = extractGlobalFlag(os.Args, "project", projectFlag)
+	if err := useProject(projectFlag); err != nil && !(len(os.Args) > 1 && (os.Args[1] == "project" || os.Args[1] == "config")) {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	// and so may --context, to use another context for a single command
+	contextFlag := cfg.Get("context.active")
+	os.Args, contextFlag = extractGlobalFlag(os.Args, "context", contextFlag)
+	if err := useContext(contextFlag); err != nil && !(len(os.Args) > 1 && (os.Args[1] == "context" || os.Args[1] == "config")) {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
@@ -27,20 +69,29 @@ func main() {
 	case "add":
 		addCmd := flag.NewFlagSet("add", flag.ExitOnError)
 
-		// Define flags
-		priority := addCmd.String("priority", "medium", "Prioriy: low, medium, high")
+		// Define flags; left empty, they come from inline tokens or the
+		// active context's defaults
+		priority := addCmd.String("priority", "", "Priority: "+currentPriorities().names())
 		category := addCmd.String("category", "", "Category for the todo")
 		dueDate := addCmd.String("due", "", "Due date: YYYY-MM-DD")
+		estimate := addCmd.String("estimate", "", "Estimated effort: a duration (2h) or story points (3pt)")
+		noParse := addCmd.Bool("no-parse", false, "Store the title as typed, without quick-add tokens")
 
 		addCmd.Parse(os.Args[2:])
 		args := addCmd.Args()
 		if len(args) < 1 {
-			fmt.Println("Usage: todo add [--priority low|medium|high] [--category name] [--due YYYY-MM-DD] <title>")
+			fmt.Printf("Usage: todo add [--priority %s] [--category name] [--due YYYY-MM-DD] [--estimate 2h|3pt] [--no-parse] <title>\n", currentPriorities().choices())
 			os.Exit(1)
 		}
-		title := args[0]
 
-		err := cmdAdd(title, Priority(*priority), *category, *dueDate)
+		err := cmdAdd(addOptions{
+			Title:    args[0],
+			Priority: Priority(*priority),
+			Category: *category,
+			DueDate:  *dueDate,
+			Estimate: *estimate,
+			NoParse:  *noParse,
+		})
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
@@ -49,18 +100,96 @@ func main() {
 		listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 		showAll := listCmd.Bool("all", false, "Show all todos")
 		showDone := listCmd.Bool("done", false, "Show only completed")
+		showPending := listCmd.Bool("pending", false, "Show only pending")
 		priority := listCmd.String("priority", "", "Filter by priority")
 		category := listCmd.String("category", "", "Filter by category")
+		sortBy := listCmd.String("sort", cfg.Get("list.sort"), "Sort by: id, due, priority, urgency, created, completed, title")
+		columns := listCmd.String("columns", cfg.Get("list.columns"), "Columns to show, e.g. id,title,due,created,age")
+		groupBy := listCmd.String("group-by", cfg.Get("list.group_by"), "Group into sections by: category, priority, status")
+		style := listCmd.String("style", cfg.Get("table.style"), "Table style: ascii, compact, markdown, unicode")
+		completedSince := listCmd.String("completed-since", "", "Show todos completed since a duration ago (7d) or date (YYYY-MM-DD)")
+		snoozed := listCmd.Bool("snoozed", false, "Show only snoozed todos")
 		listCmd.Parse(os.Args[2:])
 
-		err := cmdList(*showAll, *showDone, Priority(*priority), *category)
+		// list.filter only applies when no filter flag was given
+		all, done, err := listFilter(*showAll, *showDone, *showPending, cfg.Get("list.filter"))
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+
+		err = cmdList(listOptions{
+			ShowAll:  all,
+			ShowDone: done,
+			Priority: Priority(*priority),
+			Category: *category,
+			Sort:     *sortBy,
+			Columns:  *columns,
+			GroupBy:  *groupBy,
+			Style:    *style,
+
+			CompletedSince: *completedSince,
+			Snoozed:        *snoozed,
+		})
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "done", "undone":
+		statusCmd := flag.NewFlagSet(command, flag.ExitOnError)
+		selFlags := addSelectionFlags(statusCmd, true)
+		args := parseFlags(statusCmd, os.Args[2:])
+
+		sel, opts, err := selFlags.selection(args)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if len(sel.IDs) == 0 && !sel.hasFilters() {
+			fmt.Printf("Usage: todo %s <id>... [--category name] [--priority level] [--force] [--strict]\n", command)
+			os.Exit(1)
+		}
+
+		switch {
+		case !sel.isSingle():
+			err = cmdBulkStatus(sel, command == "done", opts)
+		case command == "done":
+			err = cmdDone(sel.IDs[0])
+		default:
+			err = cmdUndone(sel.IDs[0])
+		}
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
-	case "done":
+	case "delete":
+		deleteCmd := flag.NewFlagSet("delete", flag.ExitOnError)
+		selFlags := addSelectionFlags(deleteCmd, true)
+		args := parseFlags(deleteCmd, os.Args[2:])
+
+		sel, opts, err := selFlags.selection(args)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if len(sel.IDs) == 0 && !sel.hasFilters() {
+			fmt.Println("Usage: todo delete <id>... [--category name] [--priority level] [--done|--pending] [--force] [--strict]")
+			os.Exit(1)
+		}
+
+		opts.Force = opts.Force || !cfg.Bool("confirm.delete")
+		if sel.isSingle() {
+			err = cmdDelete(sel.IDs[0], opts.Force)
+		} else {
+			err = cmdBulkDelete(sel, opts)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "show":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: todo done <id>")
+			fmt.Println("Usage: todo show <id>")
 			os.Exit(1)
 		}
 
@@ -69,14 +198,14 @@ func main() {
 			fmt.Println("Error: invalid ID")
 			os.Exit(1)
 		}
-		err = cmdDone(id)
+		err = cmdShow(id)
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
-	case "undone":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: todo undone <id>")
+	case "move":
+		if len(os.Args) < 4 {
+			fmt.Println("Usage: todo move <id> <state>")
 			os.Exit(1)
 		}
 
@@ -85,19 +214,18 @@ func main() {
 			fmt.Println("Error: invalid ID")
 			os.Exit(1)
 		}
-		err = cmdUndone(id)
+		err = cmdMove(id, os.Args[3])
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
-	case "delete":
-		deleteCmd := flag.NewFlagSet("delete", flag.ExitOnError)
-		force := deleteCmd.Bool("force", false, "Skip confirmation")
-		deleteCmd.Parse(os.Args[2:])
+	case "snooze":
+		snoozeCmd := flag.NewFlagSet("snooze", flag.ExitOnError)
+		until := snoozeCmd.String("until", "", "Date to snooze until: YYYY-MM-DD, a weekday, tomorrow")
+		args := parseFlags(snoozeCmd, os.Args[2:])
 
-		args := deleteCmd.Args()
-		if len(args) < 1 {
-			fmt.Println("Usage: todo delete <id> [--force]")
+		if len(args) == 0 || (len(args) < 2) == (*until == "") {
+			fmt.Println("Usage: todo snooze <id> <duration> | todo snooze <id> --until <date>")
 			os.Exit(1)
 		}
 
@@ -106,14 +234,18 @@ func main() {
 			fmt.Println("Error: invalid ID")
 			os.Exit(1)
 		}
-		err = cmdDelete(id, *force)
+		when := *until
+		if when == "" {
+			when = args[1]
+		}
+		err = cmdSnooze(id, when)
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
-	case "show":
+	case "unsnooze":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: todo show <id>")
+			fmt.Println("Usage: todo unsnooze <id>")
 			os.Exit(1)
 		}
 
@@ -122,14 +254,14 @@ func main() {
 			fmt.Println("Error: invalid ID")
 			os.Exit(1)
 		}
-		err = cmdShow(id)
+		err = cmdUnsnooze(id)
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
 		}
-	case "edit":
+	case "start":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: todo edit <id> [--title text] [--due YYYY-MM-DD] [--priority low|medium|high] [--category name]")
+			fmt.Println("Usage: todo start <id>")
 			os.Exit(1)
 		}
 
@@ -138,16 +270,208 @@ func main() {
 			fmt.Println("Error: invalid ID")
 			os.Exit(1)
 		}
+		err = cmdStart(id)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "stop":
+		err := cmdStop()
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "log-time":
+		logCmd := flag.NewFlagSet("log-time", flag.ExitOnError)
+		date := logCmd.String("date", "", "Day the time was spent: YYYY-MM-DD or days ago, e.g. 1d")
+		args := parseFlags(logCmd, os.Args[2:])
 
+		if len(args) < 2 {
+			fmt.Println("Usage: todo log-time <id> <duration> [--date <date>]")
+			os.Exit(1)
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error: invalid ID")
+			os.Exit(1)
+		}
+		err = cmdLogTime(id, args[1], *date)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "timesheet":
+		timesheetCmd := flag.NewFlagSet("timesheet", flag.ExitOnError)
+		timesheetCmd.Bool("week", true, "Show the whole week (the default)")
+		day := timesheetCmd.Bool("day", false, "Show a single day")
+		date := timesheetCmd.String("date", "", "Show the week or day of a YYYY-MM-DD date or days ago, e.g. 1w")
+		timesheetCmd.Parse(os.Args[2:])
+
+		err := cmdTimesheet(*day, *date)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "estimate":
+		estimateCmd := flag.NewFlagSet("estimate", flag.ExitOnError)
+		clearEstimate := estimateCmd.Bool("clear", false, "Remove the estimate")
+		args := parseFlags(estimateCmd, os.Args[2:])
+
+		if len(args) == 0 || (len(args) < 2) == !*clearEstimate {
+			fmt.Println("Usage: todo estimate <id> <2h|3pt> | todo estimate <id> --clear")
+			os.Exit(1)
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error: invalid ID")
+			os.Exit(1)
+		}
+		value := ""
+		if !*clearEstimate {
+			value = args[1]
+		}
+		err = cmdEstimate(id, value)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "estimates":
+		estimatesCmd := flag.NewFlagSet("estimates", flag.ExitOnError)
+		category := estimatesCmd.String("category", "", "Only todos in a category")
+		since := estimatesCmd.String("since", "", "Only todos completed since a duration ago (30d) or date (YYYY-MM-DD)")
+		estimatesCmd.Parse(os.Args[2:])
+
+		err := cmdEstimates(*category, *since)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "pomodoro":
+		pomodoroCmd := flag.NewFlagSet("pomodoro", flag.ExitOnError)
+		work := pomodoroCmd.String("work", "", "Length of a pomodoro, e.g. 25m (default pomodoro.work)")
+		breakTime := pomodoroCmd.String("break", "", "Length of the breaks, e.g. 5m (default pomodoro.break)")
+		cycles := pomodoroCmd.Int("cycles", 0, "Pomodoros in the session (default pomodoro.cycles)")
+		summary := pomodoroCmd.Bool("summary", false, "Show the pomodoros finished per day and todo")
+		since := pomodoroCmd.String("since", "7d", "With --summary, since a duration ago (7d) or date (YYYY-MM-DD)")
+		args := parseFlags(pomodoroCmd, os.Args[2:])
+
+		if *summary {
+			err := cmdPomodoroSummary(*since)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			break
+		}
+
+		if len(args) < 1 {
+			fmt.Println("Usage: todo pomodoro <id> [--work 25m] [--break 5m] [--cycles 4] | todo pomodoro --summary [--since 7d]")
+			os.Exit(1)
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Error: invalid ID")
+			os.Exit(1)
+		}
+		err = cmdPomodoro(id, *work, *breakTime, *cycles)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "next":
+		nextCmd := flag.NewFlagSet("next", flag.ExitOnError)
+		category := nextCmd.String("category", "", "Only consider todos in a category")
+		nextCmd.Parse(os.Args[2:])
+
+		err := cmdNext(*category)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "priorities":
+		prioritiesCmd := flag.NewFlagSet("priorities", flag.ExitOnError)
+		mapping := prioritiesCmd.String("map", "", "Where old priorities go, e.g. high:P1,medium:P2")
+		dryRun := prioritiesCmd.Bool("dry-run", false, "Show what would change without changing it")
+		args := parseFlags(prioritiesCmd, os.Args[2:])
+
+		var err error
+		switch {
+		case len(args) == 0:
+			err = cmdPriorities()
+		case args[0] == "migrate":
+			err = cmdPrioritiesMigrate(*mapping, *dryRun)
+		default:
+			err = fmt.Errorf("unknown priorities command %q. Use migrate", args[0])
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "board":
+		boardCmd := flag.NewFlagSet("board", flag.ExitOnError)
+		category := boardCmd.String("category", "", "Only show todos in a category")
+		allDone := boardCmd.Bool("all", false, "Show every done todo, not just the last week's")
+		boardCmd.Parse(os.Args[2:])
+
+		err := cmdBoard(*category, *allDone)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "edit":
 		editCmd := flag.NewFlagSet("edit", flag.ExitOnError)
 		title := editCmd.String("title", "", "New title")
 		priority := editCmd.String("priority", "", "New priority")
 		category := editCmd.String("category", "", "New category")
 		dueDate := editCmd.String("due", "", "Due date: YYYY-MM-DD")
+		interactive := editCmd.Bool("i", false, "Edit all fields in $EDITOR")
+		selFlags := addSelectionFlags(editCmd, false)
+		args := parseFlags(editCmd, os.Args[2:])
 
-		editCmd.Parse(os.Args[3:])
+		sel, opts, err := selFlags.selection(args)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		if len(sel.IDs) == 0 {
+			fmt.Printf("Usage: todo edit <id>... [-i] [--title text] [--due YYYY-MM-DD] [--priority %s] [--category name] [--force] [--strict]\n", currentPriorities().choices())
+			os.Exit(1)
+		}
 
-		err = cmdEdit(id, *title, Priority(*priority), *category, *dueDate)
+		switch {
+		case *interactive && !sel.isSingle():
+			err = fmt.Errorf("-i edits one todo at a time")
+		case *interactive:
+			err = cmdEditInteractive(sel.IDs[0])
+		case sel.isSingle():
+			err = cmdEdit(sel.IDs[0], *title, Priority(*priority), *category, *dueDate)
+		default:
+			err = cmdBulkEdit(sel, *title, Priority(*priority), *category, *dueDate, opts)
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "note":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: todo note <id> [--append text]")
+			os.Exit(1)
+		}
+
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			fmt.Println("Error: invalid ID")
+			os.Exit(1)
+		}
+
+		noteCmd := flag.NewFlagSet("note", flag.ExitOnError)
+		appendText := noteCmd.String("append", "", "Append text instead of opening $EDITOR")
+		noteCmd.Parse(os.Args[3:])
+
+		err = cmdNote(id, *appendText)
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
@@ -155,9 +479,234 @@ func main() {
 	case "clear":
 		clearCmd := flag.NewFlagSet("clear", flag.ExitOnError)
 		clearAll := clearCmd.Bool("all", false, "Clear ALL todos")
+		olderThan := clearCmd.String("older-than", "", "Only clear todos completed more than this long ago (30d, 2w)")
+		force := clearCmd.Bool("force", !cfg.Bool("confirm.clear"), "Skip confirmation")
 		clearCmd.Parse(os.Args[2:])
 
-		err := cmdClear(*clearAll)
+		err := cmdClear(*clearAll, *olderThan, *force)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "history":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: todo history <id>")
+			os.Exit(1)
+		}
+
+		id, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			fmt.Println("Error: invalid ID")
+			os.Exit(1)
+		}
+		err = cmdHistory(id)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "log":
+		logCmd := flag.NewFlagSet("log", flag.ExitOnError)
+		since := logCmd.String("since", "7d", "Show activity since a duration ago (7d, 12h) or date (YYYY-MM-DD)")
+		logCmd.Parse(os.Args[2:])
+
+		err := cmdLog(*since)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "agenda":
+		agendaCmd := flag.NewFlagSet("agenda", flag.ExitOnError)
+		days := agendaCmd.Int("days", 14, "Only show todos due within this many days (0 for all)")
+		agendaCmd.Parse(os.Args[2:])
+
+		err := cmdAgenda(*days)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "calendar":
+		calendarCmd := flag.NewFlagSet("calendar", flag.ExitOnError)
+		month := calendarCmd.String("month", "", "Month to show: YYYY-MM (default: this month)")
+		expand := calendarCmd.Bool("expand", false, "List the todos due on each day")
+		calendarCmd.Parse(os.Args[2:])
+
+		err := cmdCalendar(*month, *expand)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "remind":
+		remindCmd := flag.NewFlagSet("remind", flag.ExitOnError)
+		daemon := remindCmd.Bool("daemon", false, "Keep running and check for reminders periodically")
+		interval := remindCmd.String("interval", "1m", "How often the daemon checks (30s, 1m, 5m)")
+		notifier := remindCmd.String("notifier", cfg.Get("remind.notifier"), "Deliver via: stdout, notify-send, exec")
+		remindCmd.Parse(os.Args[2:])
+
+		err := cmdRemind(*daemon, *interval, *notifier)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "stats":
+		statsCmd := flag.NewFlagSet("stats", flag.ExitOnError)
+		format := statsCmd.String("format", "text", "Output format: text or json")
+		days := statsCmd.Int("days", 14, "Days of completions to chart")
+		weeks := statsCmd.Int("weeks", 8, "Weeks of completions to chart")
+		statsCmd.Parse(os.Args[2:])
+
+		err := cmdStats(*format, *days, *weeks)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "report":
+		reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+		format := reportCmd.String("format", "markdown", "Output format: markdown or html")
+		since := reportCmd.String("since", "", "Also include todos completed since a duration ago (7d) or date (YYYY-MM-DD)")
+		reportCmd.Parse(os.Args[2:])
+
+		err := cmdReport(*format, *since)
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "config":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: todo config list | get <setting> | set <setting> <value>")
+			os.Exit(1)
+		}
+
+		switch os.Args[2] {
+		case "list":
+			err = cmdConfigList()
+		case "get":
+			if len(os.Args) < 4 {
+				fmt.Println("Usage: todo config get <setting>")
+				os.Exit(1)
+			}
+			err = cmdConfigGet(os.Args[3])
+		case "set":
+			if len(os.Args) < 5 {
+				fmt.Println("Usage: todo config set <setting> <value>")
+				os.Exit(1)
+			}
+			err = cmdConfigSet(os.Args[3], os.Args[4])
+		default:
+			err = fmt.Errorf("unknown config command %q. Use list, get or set", os.Args[2])
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "project":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: todo project create|list|archive|unarchive|rename|set|unset|assign|unassign ...")
+			os.Exit(1)
+		}
+
+		args := os.Args[3:]
+		switch os.Args[2] {
+		case "create", "archive", "unarchive":
+			if len(args) != 1 {
+				fmt.Printf("Usage: todo project %s <name>\n", os.Args[2])
+				os.Exit(1)
+			}
+			if os.Args[2] == "create" {
+				err = cmdProjectCreate(args[0])
+			} else {
+				err = cmdProjectArchive(args[0], os.Args[2] == "archive")
+			}
+		case "list":
+			listCmd := flag.NewFlagSet("project list", flag.ExitOnError)
+			all := listCmd.Bool("all", false, "Include archived projects")
+			listCmd.Parse(args)
+			err = cmdProjectList(*all)
+		case "rename":
+			if len(args) != 2 {
+				fmt.Println("Usage: todo project rename <name> <new name>")
+				os.Exit(1)
+			}
+			err = cmdProjectRename(args[0], args[1])
+		case "set":
+			if len(args) != 3 {
+				fmt.Println("Usage: todo project set <name> <setting> <value>")
+				os.Exit(1)
+			}
+			err = cmdProjectSet(args[0], args[1], args[2])
+		case "unset":
+			if len(args) != 2 {
+				fmt.Println("Usage: todo project unset <name> <setting>")
+				os.Exit(1)
+			}
+			err = cmdProjectSet(args[0], args[1], "")
+		case "assign", "unassign":
+			usage := "Usage: todo project unassign <id>..."
+			name := ""
+			if os.Args[2] == "assign" && len(args) > 0 {
+				usage = "Usage: todo project assign <name> <id>..."
+				name, args = args[0], args[1:]
+			}
+			ids, perr := parseIDList(args)
+			if perr != nil || len(ids) == 0 {
+				fmt.Println(usage)
+				os.Exit(1)
+			}
+			err = cmdProjectAssign(name, ids)
+		default:
+			err = fmt.Errorf("unknown project command %q. Use create, list, archive, unarchive, rename, set, unset, assign or unassign", os.Args[2])
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "context":
+		if len(os.Args) < 3 {
+			err = cmdContextShow()
+		} else {
+			args := os.Args[3:]
+			switch os.Args[2] {
+			case "define":
+				defineCmd := flag.NewFlagSet("context define", flag.ExitOnError)
+				category := defineCmd.String("category", "", "Only todos in this category, and their category when added")
+				priority := defineCmd.String("priority", "", "Only todos with this priority, and their priority when added")
+				args = parseFlags(defineCmd, args)
+				if len(args) != 1 {
+					fmt.Println("Usage: todo context define <name> [--category name] [--priority level]")
+					os.Exit(1)
+				}
+				err = cmdContextDefine(args[0], *category, Priority(*priority))
+			case "use", "delete":
+				if len(args) != 1 {
+					fmt.Printf("Usage: todo context %s <name>\n", os.Args[2])
+					os.Exit(1)
+				}
+				if os.Args[2] == "use" {
+					err = cmdContextUse(args[0])
+				} else {
+					err = cmdContextDelete(args[0])
+				}
+			case "none":
+				err = cmdContextUse("")
+			case "list":
+				err = cmdContextList()
+			default:
+				err = fmt.Errorf("unknown context command %q. Use define, use, none, list or delete", os.Args[2])
+			}
+		}
+		if err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	case "undo", "redo":
+		replayCmd := flag.NewFlagSet(command, flag.ExitOnError)
+		steps := replayCmd.Int("steps", 1, "Number of commands to "+command)
+		replayCmd.Parse(os.Args[2:])
+
+		if command == "undo" {
+			err = cmdUndo(*steps)
+		} else {
+			err = cmdRedo(*steps)
+		}
 		if err != nil {
 			fmt.Println("Error:", err)
 			os.Exit(1)
@@ -171,36 +720,284 @@ func main() {
 
 }
 
+// globalFlags are the flags every command takes. They go before the command
+// name or straight after it, ahead of the command's own options and
+// arguments, so titles and other values are never mistaken for them.
+var globalFlags = []string{"color", "project", "context"}
+
+// globalFlagAt reports whether args[i] starts a global flag, returning its
+// name and value and how many args it spans, or 0 if it isn't one.
+func globalFlagAt(args []string, i int) (string, string, int) {
+	for _, name := range globalFlags {
+		for _, prefix := range []string{"--" + name, "-" + name} {
+			if v, ok := strings.CutPrefix(args[i], prefix+"="); ok {
+				return name, v, 1
+			}
+			if args[i] == prefix && i+1 < len(args) {
+				return name, args[i+1], 2
+			}
+		}
+	}
+	return "", "", 0
+}
+
+// extractGlobalFlag removes --name=value or --name value from args and
+// returns the remaining args with the value, or def if the flag is absent.
+// Only the global flags before the command name and right after it are
+// looked at; scanning stops at the first other argument after the command.
+func extractGlobalFlag(args []string, name, def string) ([]string, string) {
+	if len(args) == 0 {
+		return args, def
+	}
+
+	value := def
+	rest := []string{args[0]}
+	command := false
+
+	i := 1
+	for i < len(args) && args[i] != "--" {
+		flagName, v, n := globalFlagAt(args, i)
+		if n == 0 {
+			if command {
+				break
+			}
+			command = !strings.HasPrefix(args[i], "-")
+			rest = append(rest, args[i])
+			i++
+			continue
+		}
+
+		if flagName == name {
+			value = v
+		} else {
+			rest = append(rest, args[i:i+n]...)
+		}
+		i += n
+	}
+
+	return append(rest, args[i:]...), value
+}
+
+// parseFlags parses args with fs, allowing flags to come after positional
+// arguments ("todo delete 3 --force"), and returns the positional ones.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+type selectionFlags struct {
+	category *string
+	priority *string
+	done     *bool
+	pending  *bool
+	force    *bool
+	strict   *bool
+}
+
+// addSelectionFlags registers the flags shared by the bulk commands. Filter
+// flags are left out for commands whose own flags use the same names.
+func addSelectionFlags(fs *flag.FlagSet, filters bool) *selectionFlags {
+	f := &selectionFlags{
+		force:  fs.Bool("force", false, "Skip confirmation"),
+		strict: fs.Bool("strict", false, "Abort if any ID is not found"),
+	}
+	if filters {
+		f.category = fs.String("category", "", "Select todos in this category")
+		f.priority = fs.String("priority", "", "Select todos with this priority")
+		f.done = fs.Bool("done", false, "Select only completed todos")
+		f.pending = fs.Bool("pending", false, "Select only pending todos")
+	}
+	return f
+}
+
+func (f *selectionFlags) selection(args []string) (todoSelection, bulkOptions, error) {
+	opts := bulkOptions{Force: *f.force, Strict: *f.strict}
+
+	ids, err := parseIDList(args)
+	if err != nil {
+		return todoSelection{}, opts, err
+	}
+
+	sel := todoSelection{IDs: ids}
+	if f.category != nil {
+		sel.Category = *f.category
+		sel.Priority = Priority(*f.priority)
+		sel.Done = *f.done
+		sel.Pending = *f.pending
+	}
+	return sel, opts, nil
+}
+
 func printUsage() {
-	fmt.Println("Usage: todo <command> [options]")
+	fmt.Println("Usage: todo [--color=auto|always|never] [--project name] [--context name] <command> [options]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  add <title>       Add a new todo")
-	fmt.Println("      --priority    Priority: low, medium, high (default: medium)")
+	fmt.Printf("      --priority    Priority: %s (default: %s)\n", currentPriorities().names(), defaultPriority())
 	fmt.Println("      --category    Category for the todo")
 	fmt.Println("      --due         Due date: YYYY-MM-DD")
+	fmt.Println("      --estimate    Estimated effort: a duration (2h) or story points (3pt)")
+	fmt.Println("      --no-parse    Don't read !priority, #category, due:date from the title")
 	fmt.Println("")
 	fmt.Println("  list              List pending todos")
 	fmt.Println("      --all         Show all todos")
 	fmt.Println("      --done        Show only completed")
+	fmt.Println("      --pending     Show only pending, whatever list.filter says")
 	fmt.Println("      --priority    Filter by priority")
 	fmt.Println("      --category    Filter by category")
+	fmt.Println("      --sort        Sort by: id, due, priority, urgency, created, completed, title")
+	fmt.Println("      --completed-since  Show todos completed since a duration ago (7d) or date")
+	fmt.Println("      --columns     Columns to show, e.g. id,title,due,created,age")
+	fmt.Println("      --group-by    Group into sections by: category, priority, status")
+	fmt.Println("      --style       Table style: unicode, ascii, markdown, compact")
+	fmt.Println("      --snoozed     Show only snoozed todos")
 	fmt.Println("")
-	fmt.Println("  done <id>         Mark a todo as complete")
+	fmt.Println("  done <id>...      Mark todos as complete (IDs: 3 5 7-12 or 4,6)")
 	fmt.Println("")
-	fmt.Println("  undone <id>       Mark a todo as incomplete")
+	fmt.Println("  undone <id>...    Mark todos as incomplete")
 	fmt.Println("")
-	fmt.Println("  delete <id>       Delete a todo")
+	fmt.Println("  delete <id>...    Delete todos")
 	fmt.Println("      --force       Skip confirmation")
 	fmt.Println("")
+	fmt.Println("  done, undone and delete also select by filter instead of IDs:")
+	fmt.Println("      --category    Todos in a category")
+	fmt.Println("      --priority    Todos with a priority")
+	fmt.Println("      --done        Only completed todos")
+	fmt.Println("      --pending     Only pending todos")
+	fmt.Println("      --strict      Abort if any ID is not found")
+	fmt.Println("")
 	fmt.Println("  show <id>         Show todo details")
 	fmt.Println("")
-	fmt.Println("  edit <id>         Edit a todo")
+	fmt.Println("  move <id> <state> Move a todo to a workflow state, e.g. in-progress")
+	fmt.Println("")
+	fmt.Println("  next              Show the most urgent todo and how its urgency adds up")
+	fmt.Println("      --category    Only todos in a category")
+	fmt.Println("")
+	fmt.Println("  snooze <id> <when>")
+	fmt.Println("                    Hide a todo from the list for a while: 4h, 3d, monday")
+	fmt.Println("      --until       Date to snooze until instead: YYYY-MM-DD, a weekday, tomorrow")
+	fmt.Println("")
+	fmt.Println("  unsnooze <id>     Bring a snoozed todo back to the list")
+	fmt.Println("")
+	fmt.Println("  pomodoro <id>     Work on a todo in pomodoros with a countdown, logging each one")
+	fmt.Println("      --work        Length of a pomodoro (default 25m)")
+	fmt.Println("      --break       Length of the breaks (default 5m)")
+	fmt.Println("      --cycles      Pomodoros in the session (default 4)")
+	fmt.Println("      --summary     Show the pomodoros finished per day and todo instead")
+	fmt.Println("      --since       With --summary, since a duration ago (7d) or date")
+	fmt.Println("")
+	fmt.Println("  estimate <id> <estimate>")
+	fmt.Println("                    Set the estimated effort of a todo: 2h, 1h30m, 3pt")
+	fmt.Println("      --clear       Remove the estimate instead")
+	fmt.Println("")
+	fmt.Println("  estimates         Compare estimates with tracked time for completed todos")
+	fmt.Println("      --category    Only todos in a category")
+	fmt.Println("      --since       Only todos completed since a duration ago (30d) or date")
+	fmt.Println("")
+	fmt.Println("  start <id>        Start a timer on a todo, stopping any running timer")
+	fmt.Println("")
+	fmt.Println("  stop              Stop the running timer")
+	fmt.Println("")
+	fmt.Println("  log-time <id> <duration>")
+	fmt.Println("                    Record time spent without a timer, e.g. 1h30m")
+	fmt.Println("      --date        Day the time was spent: YYYY-MM-DD or days ago, e.g. 1d")
+	fmt.Println("")
+	fmt.Println("  timesheet         Show time tracked this week per todo and category")
+	fmt.Println("      --week        Show the whole week (the default)")
+	fmt.Println("      --day         Show a single day")
+	fmt.Println("      --date        Show another week or day: YYYY-MM-DD or time ago, e.g. 1w")
+	fmt.Println("")
+	fmt.Println("  board             Show todos as a kanban board, one column per state")
+	fmt.Println("      --category    Only todos in a category")
+	fmt.Println("      --all         Show every done todo, not just the last week's")
+	fmt.Println("")
+	fmt.Println("  edit <id>...      Edit todos")
+	fmt.Println("      -i            Edit all fields and notes in $EDITOR")
 	fmt.Println("      --title       New title")
-	fmt.Println("      --priority    New priority: low, medium, high")
+	fmt.Printf("      --priority    New priority: %s\n", currentPriorities().names())
 	fmt.Println("      --category    New category")
 	fmt.Println("      --due         New due date: YYYY-MM-DD")
 	fmt.Println("")
+	fmt.Println("  note <id>         Edit the notes of a todo in $EDITOR")
+	fmt.Println("      --append      Append text instead of opening the editor")
+	fmt.Println("")
 	fmt.Println("  clear             Remove completed todos")
 	fmt.Println("      --all         Clear ALL todos (including pending)")
+	fmt.Println("      --older-than  Only todos completed more than this long ago (30d)")
+	fmt.Println("      --force       Skip confirmation")
+	fmt.Println("")
+	fmt.Println("  priorities        Show the priority levels and how many todos use each")
+	fmt.Println("  priorities migrate")
+	fmt.Println("                    Move todos with priorities not in priority.levels onto the scale")
+	fmt.Println("      --map         Where old priorities go, e.g. high:P1,medium:P2")
+	fmt.Println("      --dry-run     Only show what would change")
+	fmt.Println("")
+	fmt.Println("  project create <name>")
+	fmt.Println("                    Add a project; use --project name with any command to work in it")
+	fmt.Println("  project list      Show projects with their todo counts and settings")
+	fmt.Println("      --all         Include archived projects")
+	fmt.Println("  project archive <name>")
+	fmt.Println("                    Hide a project and its todos; project unarchive brings them back")
+	fmt.Println("  project rename <name> <new name>")
+	fmt.Println("  project set <name> <setting> <value>")
+	fmt.Println("                    Override a setting in the project, e.g. defaults.priority")
+	fmt.Println("  project unset <name> <setting>")
+	fmt.Println("  project assign <name> <id>...")
+	fmt.Println("                    Move todos into a project; project unassign <id>... takes them out")
+	fmt.Println("")
+	fmt.Println("  context define <name>")
+	fmt.Println("                    Define a context that list, show and clear keep to")
+	fmt.Println("      --category    Only todos in this category; new todos get it too")
+	fmt.Println("      --priority    Only todos with this priority; new todos get it too")
+	fmt.Println("  context use <name>")
+	fmt.Println("                    Use a context from now on; --context name uses one for a single command")
+	fmt.Println("  context none      Stop using a context")
+	fmt.Println("  context list      Show the contexts")
+	fmt.Println("  context delete <name>")
+	fmt.Println("  context           Show the context in use")
+	fmt.Println("")
+	fmt.Println("  config list       Show all settings and where they come from")
+	fmt.Println("  config get <key>  Show one setting")
+	fmt.Println("  config set <key> <value>")
+	fmt.Println("                    Save a setting to the config file")
+	fmt.Println("")
+	fmt.Println("  history <id>      Show the change history of a todo")
+	fmt.Println("")
+	fmt.Println("  log               Show recent activity across all todos")
+	fmt.Println("      --since       Duration (7d, 12h) or date YYYY-MM-DD (default: 7d)")
+	fmt.Println("")
+	fmt.Println("  agenda            Show pending todos by when they are due")
+	fmt.Println("      --days        Only todos due within this many days (default: 14, 0 for all)")
+	fmt.Println("")
+	fmt.Println("  calendar          Show a month with the number of todos due each day")
+	fmt.Println("      --month       Month to show: YYYY-MM (default: this month)")
+	fmt.Println("      --expand      List the todos due on each day")
+	fmt.Println("")
+	fmt.Println("  remind            Send reminders for todos coming due")
+	fmt.Println("      --daemon      Keep running and check periodically")
+	fmt.Println("      --interval    How often the daemon checks (default: 1m)")
+	fmt.Println("      --notifier    Deliver via: stdout, notify-send, exec")
+	fmt.Println("")
+	fmt.Println("  stats             Show totals, completion rate and completions over time")
+	fmt.Println("      --format      text (default) or json")
+	fmt.Println("      --days        Days of completions to chart (default: 14)")
+	fmt.Println("      --weeks       Weeks of completions to chart (default: 8)")
+	fmt.Println("")
+	fmt.Println("  report            Print a status report grouped by category")
+	fmt.Println("      --format      markdown (default) or html")
+	fmt.Println("      --since       Include todos completed since a duration ago (7d) or date")
+	fmt.Println("")
+	fmt.Println("  undo              Revert the last command")
+	fmt.Println("      --steps       Number of commands to revert (default: 1)")
+	fmt.Println("")
+	fmt.Println("  redo              Re-apply the last undone command")
+	fmt.Println("      --steps       Number of commands to re-apply (default: 1)")
 }
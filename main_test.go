@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractGlobalFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		flag      string
+		wantArgs  string
+		wantValue string
+	}{
+		{name: "before the command", args: "todo --project web list", flag: "project", wantArgs: "todo list", wantValue: "web"},
+		{name: "after the command", args: "todo list --project=web --all", flag: "project", wantArgs: "todo list --all", wantValue: "web"},
+		{name: "other globals kept", args: "todo --color never add --project web Title", flag: "project", wantArgs: "todo --color never add Title", wantValue: "web"},
+		{name: "absent", args: "todo list --all", flag: "color", wantArgs: "todo list --all", wantValue: "auto"},
+		{name: "title word", args: "todo add Fix --color", flag: "color", wantArgs: "todo add Fix --color", wantValue: "auto"},
+		{name: "after the options", args: "todo list --all --context work", flag: "context", wantArgs: "todo list --all --context work", wantValue: "auto"},
+		{name: "after --", args: "todo add -- --project", flag: "project", wantArgs: "todo add -- --project", wantValue: "auto"},
+		{name: "missing value", args: "todo list --color", flag: "color", wantArgs: "todo list --color", wantValue: "auto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, value := extractGlobalFlag(strings.Fields(tt.args), tt.flag, "auto")
+			if got := strings.Join(args, " "); got != tt.wantArgs || value != tt.wantValue {
+				t.Errorf("extractGlobalFlag(%q, %s) = %q, %q, want %q, %q", tt.args, tt.flag, got, value, tt.wantArgs, tt.wantValue)
+			}
+		})
+	}
+}
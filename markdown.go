@@ -0,0 +1,53 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	codeSpanRe = regexp.MustCompile("`([^`]+)`")
+	boldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	bulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// renderMarkdown formats a small subset of markdown for the terminal:
+// headings, bullet lists, fenced code blocks, bold and code spans. Anything
+// else is printed as written.
+func renderMarkdown(text string) []string {
+	var lines []string
+	inFence := false
+
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			lines = append(lines, colorize(Cyan, "    "+line))
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			lines = append(lines, colorize(Bold, heading))
+			continue
+		}
+
+		if m := bulletRe.FindStringSubmatch(line); m != nil {
+			line = m[1] + "• " + line[len(m[0]):]
+		}
+
+		line = boldRe.ReplaceAllStringFunc(line, func(m string) string {
+			return colorize(Bold, boldRe.FindStringSubmatch(m)[1])
+		})
+		line = codeSpanRe.ReplaceAllStringFunc(line, func(m string) string {
+			return colorize(Cyan, codeSpanRe.FindStringSubmatch(m)[1])
+		})
+		lines = append(lines, line)
+	}
+
+	return lines
+}
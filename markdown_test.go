@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "plain text",
+			input: "Call the bank",
+			want:  []string{"Call the bank"},
+		},
+		{
+			name:  "heading",
+			input: "## Steps",
+			want:  []string{colorize(Bold, "Steps")},
+		},
+		{
+			name:  "bullets",
+			input: "- one\n  * two",
+			want:  []string{"• one", "  • two"},
+		},
+		{
+			name:  "code span",
+			input: "run `make test`",
+			want:  []string{"run " + colorize(Cyan, "make test")},
+		},
+		{
+			name:  "bold",
+			input: "**important** stuff",
+			want:  []string{colorize(Bold, "important") + " stuff"},
+		},
+		{
+			name:  "fenced code block",
+			input: "```\n# not a heading\n```",
+			want:  []string{colorize(Cyan, "    # not a heading")},
+		},
+		{
+			name:  "trailing newlines trimmed",
+			input: "line\n\n",
+			want:  []string{"line"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderMarkdown(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("renderMarkdown(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("renderMarkdown(%q)[%d] = %q, want %q", tt.input, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -7,18 +7,23 @@ import (
 
 type Priority string
 
+// The levels of the default priority scale. priority.levels can replace
+// them with a scale of its own.
 const (
 	PriorityLow    Priority = "low"
 	PriorityMedium Priority = "medium"
 	PriorityHigh   Priority = "high"
 )
 
+// IsValid reports whether p is a level of the configured priority scale.
 func (p Priority) IsValid() bool {
-	switch p {
-	case PriorityLow, PriorityMedium, PriorityHigh:
-		return true
-	}
-	return false
+	return currentPriorities().index(p) >= 0
+}
+
+// Rank orders priorities from least (1) to most (the number of levels)
+// important. Unknown priorities rank 0.
+func (p Priority) Rank() int {
+	return currentPriorities().rank(p)
 }
 
 type Todo struct {
@@ -29,4 +34,22 @@ type Todo struct {
 	Category  string
 	CreatedAt time.Time
 	DueDate   sql.NullTime
+	Notes     string
+
+	// CompletedAt is when the todo was last marked done, unset while pending.
+	CompletedAt sql.NullTime
+
+	// State is the workflow state set by move. Empty means the todo was
+	// never moved and sits in the initial or terminal state per Done.
+	State string
+
+	// WaitUntil is when a snoozed todo comes back to the pending list.
+	WaitUntil sql.NullTime
+
+	// Estimate is the expected effort as written by effort.String: a
+	// duration such as 2h30m, story points such as 3pt, or empty.
+	Estimate string
+
+	// ProjectID is the project the todo belongs to, 0 for none.
+	ProjectID int
 }
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pomodoroPlan is a session of Cycles pomodoros of Work each, with a
+// Break after every pomodoro but the last.
+type pomodoroPlan struct {
+	Work   time.Duration
+	Break  time.Duration
+	Cycles int
+}
+
+// pomodoroPlanFrom reads a plan from the given flags, falling back to the
+// pomodoro settings for any left empty or zero.
+func pomodoroPlanFrom(work, breakTime string, cycles int) (pomodoroPlan, error) {
+	if work == "" {
+		work = cfg.Get("pomodoro.work")
+	}
+	if breakTime == "" {
+		breakTime = cfg.Get("pomodoro.break")
+	}
+	if cycles == 0 {
+		cycles, _ = strconv.Atoi(cfg.Get("pomodoro.cycles"))
+	}
+
+	var plan pomodoroPlan
+	var err error
+	if plan.Work, err = parseDuration(work); err != nil {
+		return plan, err
+	}
+	if plan.Break, err = parseDuration(breakTime); err != nil {
+		return plan, err
+	}
+	plan.Cycles = cycles
+
+	if plan.Work < time.Minute {
+		return plan, fmt.Errorf("--work must be at least a minute")
+	}
+	if plan.Cycles < 1 {
+		return plan, fmt.Errorf("--cycles must be at least 1")
+	}
+	return plan, nil
+}
+
+// countdown waits for d in steps of a second until stop fires, redrawing
+// the time left on a terminal. It reports whether d ran out uninterrupted.
+func countdown(label string, d time.Duration, stop <-chan os.Signal) bool {
+	end := clock().Add(d)
+	live := isTerminal(os.Stdout)
+	for {
+		left := end.Sub(clock())
+		if left <= 0 {
+			if live {
+				fmt.Print("\r\033[K")
+			}
+			return true
+		}
+		if live {
+			fmt.Printf("\r\033[K%s %s", label, formatClock(left))
+		}
+		if !sleep(min(time.Second, left), stop) {
+			if live {
+				fmt.Print("\r\033[K")
+			}
+			return false
+		}
+	}
+}
+
+// formatClock renders time left as mm:ss, rounding up so a countdown never
+// shows 00:00 while still running.
+func formatClock(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%02d:%02d", secs/60, secs%60)
+}
+
+// runPomodoros works through plan on todo. Each pomodoro runs as the timer,
+// so start and stop see it, and is logged whole as it ends. When stop fires
+// during a pomodoro the time worked so far is logged as a partial one;
+// during a break nothing more is logged. If another timer takes over, the
+// session ends with the pomodoro logged as partial up to then. It returns
+// the number of finished pomodoros.
+func runPomodoros(todo *Todo, plan pomodoroPlan, stop <-chan os.Signal) (int, error) {
+	for cycle := 1; cycle <= plan.Cycles; cycle++ {
+		fmt.Printf("%s Pomodoro %d/%d on #%d: %s (%s)\n", colorize(roleColor("overdue"), "●"), cycle, plan.Cycles,
+			todo.ID, todo.Title, shortDuration(plan.Work))
+
+		start := clock()
+		entry, _, err := startEntry(todo.ID, start, entryPartialPomodoro)
+		if err != nil {
+			return cycle - 1, err
+		}
+
+		label := fmt.Sprintf("%s %d/%d", colorize(Bold, "Work"), cycle, plan.Cycles)
+		if !countdown(label, plan.Work, stop) {
+			worked := clock().Sub(start)
+			if worked < time.Minute {
+				if err := discardEntry(entry); err != nil {
+					return cycle - 1, err
+				}
+				fmt.Println("Stopped before a minute was up, nothing logged")
+				return cycle - 1, nil
+			}
+			if _, err := endEntry(entry, clock(), entryPartialPomodoro); err != nil {
+				return cycle - 1, err
+			}
+			fmt.Printf("Stopped, logged a partial pomodoro of %s\n", formatSpent(worked))
+			return cycle - 1, nil
+		}
+
+		running, err := endEntry(entry, start.Add(plan.Work), entryPomodoro)
+		if err != nil {
+			return cycle - 1, err
+		}
+		if !running {
+			fmt.Println("The timer was stopped or moved to another todo, logged a partial pomodoro")
+			return cycle - 1, nil
+		}
+		fmt.Printf("\a%s Pomodoro %d/%d done\n", colorize(Green, "✓"), cycle, plan.Cycles)
+
+		if cycle == plan.Cycles || plan.Break == 0 {
+			continue
+		}
+		fmt.Printf("  Break for %s\n", shortDuration(plan.Break))
+		if !countdown(colorize(Bold, "Break"), plan.Break, stop) {
+			fmt.Println("Stopped during the break")
+			return cycle, nil
+		}
+		fmt.Print("\a")
+	}
+	return plan.Cycles, nil
+}
+
+// cmdPomodoro runs a pomodoro session on todo id in the terminal. A timer
+// running on any todo is stopped first so time isn't counted twice.
+func cmdPomodoro(id int, work, breakTime string, cycles int) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+	if todo.Done {
+		return fmt.Errorf("todo #%d is done. Use undone to reopen it first", id)
+	}
+
+	plan, err := pomodoroPlanFrom(work, breakTime, cycles)
+	if err != nil {
+		return err
+	}
+
+	now := clock()
+	stopped, err := stopTimer(now)
+	if err != nil {
+		return err
+	}
+	if stopped != nil {
+		fmt.Printf("%s Stopped timer on #%d after %s\n", colorize(Blue, "■"), stopped.TodoID, formatSpent(stopped.duration(now)))
+	}
+
+	fmt.Println("Press Ctrl-C to stop; a pomodoro cut short is logged as partial.")
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	done, err := runPomodoros(todo, plan, stop)
+	if err != nil {
+		return err
+	}
+
+	total, partial, err := pomodoroCounts(id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d pomodoro(s) this session, %s\n", done, describePomodoros(total, partial))
+	return nil
+}
+
+// pomodoroCounts counts the finished and partial pomodoros logged on todo id.
+func pomodoroCounts(id int) (done, partial int, err error) {
+	err = db.QueryRow(`
+	SELECT
+		COALESCE(SUM(kind = ?), 0),
+		COALESCE(SUM(kind = ?), 0)
+	FROM time_entries WHERE todo_id = ?`, entryPomodoro, entryPartialPomodoro, id).Scan(&done, &partial)
+	return done, partial, err
+}
+
+func describePomodoros(done, partial int) string {
+	s := fmt.Sprintf("%d in total", done)
+	if partial > 0 {
+		s += fmt.Sprintf(" (and %d partial)", partial)
+	}
+	return s
+}
+
+// printPomodoros adds the pomodoro count to show, for todos that have any.
+func printPomodoros(id int) error {
+	done, partial, err := pomodoroCounts(id)
+	if err != nil || done+partial == 0 {
+		return err
+	}
+	fmt.Printf("  Pomodoros: %s\n", describePomodoros(done, partial))
+	return nil
+}
+
+// pomodoroDay is the pomodoros finished on one day, counted per todo.
+type pomodoroDay struct {
+	Day    time.Time
+	Counts map[int]int
+	Total  int
+}
+
+// summarizePomodoros groups finished pomodoros by the day they started.
+func summarizePomodoros(entries []timeEntry) []pomodoroDay {
+	byDay := map[time.Time]*pomodoroDay{}
+	for _, e := range entries {
+		if e.Kind != entryPomodoro {
+			continue
+		}
+		day := startOfDay(e.Start.Local())
+		d, ok := byDay[day]
+		if !ok {
+			d = &pomodoroDay{Day: day, Counts: map[int]int{}}
+			byDay[day] = d
+		}
+		d.Counts[e.TodoID]++
+		d.Total++
+	}
+
+	days := make([]pomodoroDay, 0, len(byDay))
+	for _, d := range byDay {
+		days = append(days, *d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day.Before(days[j].Day) })
+	return days
+}
+
+// cmdPomodoroSummary shows the pomodoros finished since a duration ago or
+// a date, per day and per todo.
+func cmdPomodoroSummary(since string) error {
+	from, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	from = startOfDay(from)
+
+	entries, err := entriesBetween(from, clock())
+	if err != nil {
+		return err
+	}
+	// Pomodoros that were running at the start of the window belong to
+	// the period before it
+	var started []timeEntry
+	for _, e := range entries {
+		if !e.Start.Before(from) {
+			started = append(started, e)
+		}
+	}
+	days := summarizePomodoros(started)
+
+	fmt.Printf("\nPomodoros since %s:\n", from.Format(cfg.Get("date.format")))
+	fmt.Println("---------------------------------------")
+	if len(days) == 0 {
+		fmt.Println("No pomodoros finished")
+		return nil
+	}
+
+	perTodo := map[int]int{}
+	total := 0
+	for _, d := range days {
+		fmt.Printf("  %s  %s %d\n", d.Day.Format("Mon 02"), colorize(roleColor("overdue"), strings.Repeat("●", d.Total)), d.Total)
+		for id, n := range d.Counts {
+			perTodo[id] += n
+		}
+		total += d.Total
+	}
+
+	ids := make([]int, 0, len(perTodo))
+	for id := range perTodo {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if perTodo[ids[i]] != perTodo[ids[j]] {
+			return perTodo[ids[i]] > perTodo[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	fmt.Println()
+	table := NewTable([]string{"ID", "Title", "Category", "Pomodoros"})
+	table.SetFlexible("Title", "Category")
+	table.SetAlign(AlignRight, "ID", "Pomodoros")
+	for _, id := range ids {
+		title, category := "(deleted)", ""
+		if todo, err := getTodoByID(id); err == nil {
+			title, category = todo.Title, todo.Category
+		}
+		table.AddRow([]string{fmt.Sprintf("%d", id), title, category, fmt.Sprintf("%d", perTodo[id])})
+	}
+	table.Print()
+
+	fmt.Printf("\n%d pomodoro(s) over %d day(s)\n\n", total, len(days))
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSleep makes sleep advance the clock instead of waiting, and fire
+// stop once the clock reaches interruptAt, if set.
+func fakeSleep(t *testing.T, start, interruptAt time.Time) {
+	now := start
+	savedClock, savedSleep := clock, sleep
+	clock = func() time.Time { return now }
+	sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+		if !interruptAt.IsZero() && !now.Add(d).Before(interruptAt) {
+			now = interruptAt
+			return false
+		}
+		now = now.Add(d)
+		return true
+	}
+	t.Cleanup(func() { clock, sleep = savedClock, savedSleep })
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 25 * time.Minute, want: "25:00"},
+		{d: 61 * time.Second, want: "01:01"},
+		{d: 500 * time.Millisecond, want: "00:01"},
+	}
+
+	for _, tt := range tests {
+		if got := formatClock(tt.d); got != tt.want {
+			t.Errorf("formatClock(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPomodoroPlanFrom(t *testing.T) {
+	t.Setenv("TODO_POMODORO_CYCLES", "2")
+
+	plan, err := pomodoroPlanFrom("50m", "", 0)
+	if err != nil {
+		t.Fatalf("pomodoroPlanFrom() error = %v", err)
+	}
+	if want := (pomodoroPlan{Work: 50 * time.Minute, Break: 5 * time.Minute, Cycles: 2}); plan != want {
+		t.Errorf("pomodoroPlanFrom() = %+v, want %+v", plan, want)
+	}
+
+	for _, bad := range []struct{ work, breakTime string }{{"30s", ""}, {"soon", ""}, {"", "later"}} {
+		if _, err := pomodoroPlanFrom(bad.work, bad.breakTime, 0); err == nil {
+			t.Errorf("pomodoroPlanFrom(%q, %q) should fail", bad.work, bad.breakTime)
+		}
+	}
+}
+
+func TestRunPomodoros(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	insertTestTodo(t, "Write report", PriorityHigh, "work", "")
+	todo, _ := getTodoByID(1)
+	plan := pomodoroPlan{Work: 25 * time.Minute, Break: 5 * time.Minute, Cycles: 3}
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name        string
+		interrupt   time.Duration
+		wantDone    int
+		wantPartial time.Duration
+		wantOutput  string
+	}{
+		{name: "whole session", wantDone: 3, wantOutput: "Pomodoro 3/3 done"},
+		{name: "stopped in the second pomodoro", interrupt: 40 * time.Minute, wantDone: 1, wantPartial: 10 * time.Minute, wantOutput: "logged a partial pomodoro of 10m"},
+		{name: "stopped in a break", interrupt: 27 * time.Minute, wantDone: 1, wantOutput: "Stopped during the break"},
+		{name: "stopped right away", interrupt: 30 * time.Second, wantDone: 0, wantOutput: "nothing logged"},
+	}
+
+	for _, tt := range tests {
+		db.Exec(`DELETE FROM time_entries`)
+		var interruptAt time.Time
+		if tt.interrupt > 0 {
+			interruptAt = start.Add(tt.interrupt)
+		}
+		fakeSleep(t, start, interruptAt)
+
+		var done int
+		var err error
+		out := stripAnsi(captureOutput(func() { done, err = runPomodoros(todo, plan, nil) }))
+		if err != nil || done != tt.wantDone || !strings.Contains(out, tt.wantOutput) {
+			t.Errorf("%s: runPomodoros() = %d, %v, output:\n%s", tt.name, done, err, out)
+		}
+
+		entries, _ := queryTimeEntries(db, `WHERE todo_id = 1`)
+		var finished int
+		var partial time.Duration
+		for _, e := range entries {
+			switch e.Kind {
+			case entryPomodoro:
+				finished++
+				if e.duration(clock()) != plan.Work {
+					t.Errorf("%s: pomodoro logged as %v", tt.name, e.duration(clock()))
+				}
+			case entryPartialPomodoro:
+				partial += e.duration(clock())
+			}
+		}
+		if finished != tt.wantDone || partial != tt.wantPartial {
+			t.Errorf("%s: logged %d pomodoros and %v partial, want %d and %v", tt.name, finished, partial, tt.wantDone, tt.wantPartial)
+		}
+	}
+}
+
+func TestRunPomodoros_RunsAsTimer(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	insertTestTodo(t, "Write report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+	todo, _ := getTodoByID(1)
+	plan := pomodoroPlan{Work: 25 * time.Minute, Break: 5 * time.Minute, Cycles: 2}
+
+	// Ten minutes in, a timer is started on #2 from another terminal
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	savedClock, savedSleep := clock, sleep
+	defer func() { clock, sleep = savedClock, savedSleep }()
+	clock = func() time.Time { return now }
+	sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+		if running, _ := runningEntry(db); running == nil || running.TodoID != 1 {
+			t.Errorf("running entry during a pomodoro = %+v, want one on #1", running)
+		}
+		now = now.Add(10 * time.Minute)
+		if err := cmdStart(2); err != nil {
+			t.Errorf("cmdStart() during a pomodoro error = %v", err)
+		}
+		sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+			now = now.Add(d)
+			return true
+		}
+		now = now.Add(d - 10*time.Minute)
+		return true
+	}
+
+	var done int
+	out := stripAnsi(captureOutput(func() { done, _ = runPomodoros(todo, plan, nil) }))
+	if done != 0 || !strings.Contains(out, "Stopped timer on #1 after 10m") || !strings.Contains(out, "logged a partial pomodoro") {
+		t.Errorf("runPomodoros() = %d, output:\n%s", done, out)
+	}
+
+	entries, _ := queryTimeEntries(db, `WHERE todo_id = 1`)
+	if len(entries) != 1 || entries[0].Kind != entryPartialPomodoro || entries[0].duration(clock()) != 10*time.Minute {
+		t.Errorf("entries on #1 = %+v, want a 10m partial pomodoro", entries)
+	}
+	if running, _ := runningEntry(db); running == nil || running.TodoID != 2 {
+		t.Errorf("running entry = %+v, want the timer on #2", running)
+	}
+}
+
+func TestCmdPomodoro(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	insertTestTodo(t, "Write report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	fakeSleep(t, start, time.Time{})
+	startTimer(2, start.Add(-time.Hour))
+
+	out := stripAnsi(captureOutput(func() {
+		if err := cmdPomodoro(1, "", "", 2); err != nil {
+			t.Fatalf("cmdPomodoro() error = %v", err)
+		}
+	}))
+	for _, want := range []string{"Stopped timer on #2 after 1h 00m", "Pomodoro 1/2 on #1: Write report (25m)", "2 pomodoro(s) this session, 2 in total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdPomodoro() missing %q:\n%s", want, out)
+		}
+	}
+
+	fakeSleep(t, start.Add(24*time.Hour), start.Add(24*time.Hour+5*time.Minute))
+	captureOutput(func() { cmdPomodoro(1, "", "", 1) })
+
+	out = stripAnsi(captureOutput(func() { cmdShow(1) }))
+	if !strings.Contains(out, "Pomodoros: 2 in total (and 1 partial)") || !strings.Contains(out, "Spent:     55m") {
+		t.Errorf("cmdShow() after pomodoros:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() {
+		if err := cmdPomodoroSummary("2026-10-19"); err != nil {
+			t.Fatalf("cmdPomodoroSummary() error = %v", err)
+		}
+	}))
+	for _, want := range []string{"Mon 19  ●● 2", "Write report", "2 pomodoro(s) over 1 day(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdPomodoroSummary() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Review") {
+		t.Errorf("cmdPomodoroSummary() should only count pomodoros:\n%s", out)
+	}
+
+	// A pomodoro running across midnight counts for the day it started
+	logTime(1, start.Add(-9*time.Hour-10*time.Minute), 25*time.Minute, entryPomodoro)
+	out = stripAnsi(captureOutput(func() { cmdPomodoroSummary("2026-10-19") }))
+	if strings.Contains(out, "Sun 18") || !strings.Contains(out, "2 pomodoro(s) over 1 day(s)") {
+		t.Errorf("cmdPomodoroSummary() counted a pomodoro started before --since:\n%s", out)
+	}
+
+	captureOutput(func() { cmdDone(1) })
+	if err := cmdPomodoro(1, "", "", 1); err == nil {
+		t.Errorf("cmdPomodoro() on a done todo should fail")
+	}
+}
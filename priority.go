@@ -0,0 +1,363 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// priorityScale is the ordered set of priority levels, most important
+// first, with optional colors per level.
+type priorityScale struct {
+	Levels []Priority
+	Colors map[Priority]Color
+}
+
+const defaultPriorityLevels = "high,medium,low"
+
+var priorityNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// parsePriorityLevels reads a comma-separated list of at least two distinct
+// levels, most important first, e.g. "P0,P1,P2,P3".
+func parsePriorityLevels(s string) ([]Priority, error) {
+	var levels []Priority
+	seen := map[string]bool{}
+	for _, field := range strings.Split(s, ",") {
+		level := strings.TrimSpace(field)
+		if level == "" {
+			continue
+		}
+		if !priorityNameRe.MatchString(level) {
+			return nil, fmt.Errorf("invalid priority level %q. Use letters, digits, - and _", level)
+		}
+		if seen[level] {
+			return nil, fmt.Errorf("priority level %q is listed twice", level)
+		}
+		seen[level] = true
+		levels = append(levels, Priority(level))
+	}
+
+	if len(levels) < 2 {
+		return nil, fmt.Errorf("a priority scale needs at least two levels, e.g. high,low")
+	}
+	return levels, nil
+}
+
+// parsePriorityColors reads per-level colors such as "P0:bold red,P1:208".
+func parsePriorityColors(s string) (map[Priority]Color, error) {
+	colors := map[Priority]Color{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		level, spec, ok := strings.Cut(field, ":")
+		if !ok || strings.TrimSpace(level) == "" {
+			return nil, fmt.Errorf("invalid priority color %q. Use level:color, e.g. P0:bold red", field)
+		}
+		color, err := parseColorSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("priority color for %s: %v", strings.TrimSpace(level), err)
+		}
+		colors[Priority(strings.TrimSpace(level))] = color
+	}
+	return colors, nil
+}
+
+func validatePriorityLevels(v string) error {
+	_, err := parsePriorityLevels(v)
+	return err
+}
+
+func validatePriorityColors(v string) error {
+	_, err := parsePriorityColors(v)
+	return err
+}
+
+// priorityScale reads the priority scale from c. Invalid settings are
+// rejected when loaded, so errors here fall back to the defaults.
+func (c *Config) priorityScale() priorityScale {
+	levels, err := parsePriorityLevels(c.Get("priority.levels"))
+	if err != nil {
+		levels, _ = parsePriorityLevels(defaultPriorityLevels)
+	}
+
+	colors, err := parsePriorityColors(c.Get("priority.colors"))
+	if err != nil {
+		colors = map[Priority]Color{}
+	}
+	return priorityScale{Levels: levels, Colors: colors}
+}
+
+// currentPriorities is the priority scale in effect, parsed once and kept
+// until the config changes, since sorting asks for it on every comparison.
+func currentPriorities() priorityScale {
+	if cfg.scale == nil {
+		scale := cfg.priorityScale()
+		cfg.scale = &scale
+	}
+	return *cfg.scale
+}
+
+// defaultPriority is the priority of new todos: defaults.priority, or the
+// middle level of the scale when that is not set.
+func defaultPriority() Priority {
+	if p := cfg.Get("defaults.priority"); p != "" {
+		return Priority(p)
+	}
+	levels := currentPriorities().Levels
+	return levels[len(levels)/2]
+}
+
+// index is the position of p in the scale, or -1 if it isn't a level.
+func (s priorityScale) index(p Priority) int {
+	for i, level := range s.Levels {
+		if level == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// rank counts from 1 for the least important level up to len(Levels) for
+// the most important one. Unknown priorities rank 0.
+func (s priorityScale) rank(p Priority) int {
+	if i := s.index(p); i >= 0 {
+		return len(s.Levels) - i
+	}
+	return 0
+}
+
+func (s priorityScale) names() string {
+	return s.join(", ")
+}
+
+// choices lists the levels as alternatives for a usage line, e.g.
+// low|medium|high.
+func (s priorityScale) choices() string {
+	return s.join("|")
+}
+
+func (s priorityScale) join(sep string) string {
+	names := make([]string, len(s.Levels))
+	for i, level := range s.Levels {
+		names[i] = string(level)
+	}
+	return strings.Join(names, sep)
+}
+
+// check returns an error naming the valid levels if p isn't one of them.
+func (s priorityScale) check(p Priority) error {
+	if s.index(p) < 0 {
+		return fmt.Errorf("invalid priority: %s. Use %s", p, s.names())
+	}
+	return nil
+}
+
+// color picks the color of a level: its priority.colors entry, else the
+// theme's priority role of the same name, else the theme role matching its
+// place on the scale, so custom scales are colored without extra setup.
+func (s priorityScale) color(p Priority) Color {
+	if color, ok := s.Colors[p]; ok {
+		return color
+	}
+
+	if role := s.role(p); role != "" {
+		return roleColor(role)
+	}
+	return Reset
+}
+
+// role is the theme role of a level: the one of the same name, else the one
+// matching its place on the scale. Priorities off the scale have none.
+func (s priorityScale) role(p Priority) string {
+	i := s.index(p)
+	switch {
+	case i < 0:
+		return ""
+	case p == PriorityHigh || p == PriorityMedium || p == PriorityLow:
+		return "priority_" + string(p)
+	case i == 0:
+		return "priority_high"
+	case i == len(s.Levels)-1:
+		return "priority_low"
+	}
+	return "priority_medium"
+}
+
+// migrationTarget picks the level a priority outside the scale moves to:
+// the explicit mapping if given, else the level at the same relative place
+// for the original low, medium and high, else fallback.
+func (s priorityScale) migrationTarget(p Priority, mapping map[Priority]Priority, fallback Priority) Priority {
+	if target, ok := mapping[p]; ok {
+		return target
+	}
+
+	original := []Priority{PriorityHigh, PriorityMedium, PriorityLow}
+	for i, level := range original {
+		if p == level {
+			pos := float64(i) / float64(len(original)-1)
+			return s.Levels[int(math.Round(pos*float64(len(s.Levels)-1)))]
+		}
+	}
+	return fallback
+}
+
+// parseMapping reads "old:new" pairs for migrate, checking that
+// every new priority is a level of the scale.
+func (s priorityScale) parseMapping(v string) (map[Priority]Priority, error) {
+	mapping := map[Priority]Priority{}
+	for _, field := range strings.Split(v, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %q. Use old:new, e.g. high:P1", field)
+		}
+		target := Priority(strings.TrimSpace(to))
+		if err := s.check(target); err != nil {
+			return nil, err
+		}
+		mapping[Priority(strings.TrimSpace(from))] = target
+	}
+	return mapping, nil
+}
+
+// priorityMove is a group of todos migrate puts on a new level.
+type priorityMove struct {
+	From  Priority
+	To    Priority
+	Todos []Todo
+}
+
+// planPriorityMigration groups the todos whose priority isn't on the scale
+// by where they go.
+func planPriorityMigration(s priorityScale, todos []Todo, mapping map[Priority]Priority, fallback Priority) []priorityMove {
+	byFrom := map[Priority]*priorityMove{}
+	var moves []*priorityMove
+	for _, todo := range todos {
+		if s.index(todo.Priority) >= 0 {
+			continue
+		}
+		move, ok := byFrom[todo.Priority]
+		if !ok {
+			move = &priorityMove{From: todo.Priority, To: s.migrationTarget(todo.Priority, mapping, fallback)}
+			byFrom[todo.Priority] = move
+			moves = append(moves, move)
+		}
+		move.Todos = append(move.Todos, todo)
+	}
+
+	sort.Slice(moves, func(i, j int) bool { return moves[i].From < moves[j].From })
+	plan := make([]priorityMove, len(moves))
+	for i, m := range moves {
+		plan[i] = *m
+	}
+	return plan
+}
+
+// cmdPriorities lists the levels of the scale with how many todos use each,
+// and any priorities in use that are not on the scale.
+func cmdPriorities() error {
+	s := currentPriorities()
+	todos, err := getTodos(todoFilter{ShowAll: true, Snoozed: true})
+	if err != nil {
+		return err
+	}
+
+	counts := map[Priority]int{}
+	for _, todo := range todos {
+		counts[todo.Priority]++
+	}
+
+	width := 0
+	for p := range counts {
+		width = max(width, displayWidth(string(p)))
+	}
+	for _, level := range s.Levels {
+		width = max(width, displayWidth(string(level)))
+	}
+
+	fmt.Println("\nPriority levels, most important first:")
+	for _, level := range s.Levels {
+		fmt.Printf("  %s %4d todo(s)\n", colorize(s.color(level), padWidth(string(level), width)), counts[level])
+		delete(counts, level)
+	}
+
+	if len(counts) > 0 {
+		fmt.Println("\nNot on the scale:")
+		var others []string
+		for p := range counts {
+			others = append(others, string(p))
+		}
+		sort.Strings(others)
+		for _, p := range others {
+			fmt.Printf("  %s %4d todo(s)\n", padWidth(p, width), counts[Priority(p)])
+		}
+		fmt.Println("\nUse priorities migrate to move them onto the scale")
+	}
+	fmt.Println()
+	return nil
+}
+
+// cmdPrioritiesMigrate moves todos whose priority is not on the scale onto
+// it, in one command that undo can revert.
+func cmdPrioritiesMigrate(mapping string, dryRun bool) error {
+	s := currentPriorities()
+	m, err := s.parseMapping(mapping)
+	if err != nil {
+		return err
+	}
+
+	fallback := defaultPriority()
+	if err := s.check(fallback); err != nil {
+		return fmt.Errorf("defaults.priority: %v", err)
+	}
+
+	todos, err := getTodos(todoFilter{ShowAll: true, Snoozed: true})
+	if err != nil {
+		return err
+	}
+
+	plan := planPriorityMigration(s, todos, m, fallback)
+	if len(plan) == 0 {
+		fmt.Println("All todos are on the priority scale")
+		return nil
+	}
+
+	for _, move := range plan {
+		fmt.Printf("  %-10s → %s  %d todo(s)\n", move.From, colorize(s.color(move.To), string(move.To)), len(move.Todos))
+	}
+	if dryRun {
+		fmt.Println("Dry run, nothing changed")
+		return nil
+	}
+
+	err = withJournal("priorities migrate", func(b *journalBatch) error {
+		for _, move := range plan {
+			for _, todo := range move.Todos {
+				err := updateTodoRow(b, todo.ID, `UPDATE todos SET priority = ? WHERE id = ?`, string(move.To), todo.ID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, move := range plan {
+		total += len(move.Todos)
+	}
+	fmt.Printf("%s Migrated %d todo(s) onto the priority scale\n", colorize(Green, "✓"), total)
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePriorityLevels(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr string
+	}{
+		{input: "high,medium,low", want: "high, medium, low"},
+		{input: " P0 , P1,P2 ", want: "P0, P1, P2"},
+		{input: "urgent", wantErr: "at least two"},
+		{input: "P0,P1,P0", wantErr: "listed twice"},
+		{input: "P0,not now", wantErr: "invalid priority level"},
+	}
+
+	for _, tt := range tests {
+		levels, err := parsePriorityLevels(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePriorityLevels(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePriorityLevels(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got := (priorityScale{Levels: levels}).names(); got != tt.want {
+			t.Errorf("parsePriorityLevels(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+// setScaleEnv sets a priority setting in the environment and drops the
+// cached scale, before and after the test.
+func setScaleEnv(t *testing.T, key, value string) {
+	t.Setenv(key, value)
+	cfg.scale = nil
+	t.Cleanup(func() { cfg.scale = nil })
+}
+
+func TestCustomPriorityScale(t *testing.T) {
+	setScaleEnv(t, "TODO_PRIORITY_LEVELS", "critical,high,normal,low,someday")
+	setScaleEnv(t, "TODO_PRIORITY_COLORS", "critical:bold 196")
+
+	if !Priority("someday").IsValid() || Priority("medium").IsValid() {
+		t.Errorf("IsValid() should follow priority.levels")
+	}
+	if Priority("critical").Rank() != 5 || Priority("someday").Rank() != 1 || Priority("medium").Rank() != 0 {
+		t.Errorf("Rank() = %d, %d, %d", Priority("critical").Rank(), Priority("someday").Rank(), Priority("medium").Rank())
+	}
+	if got := defaultPriority(); got != "normal" {
+		t.Errorf("defaultPriority() = %q, want the middle level", got)
+	}
+
+	tests := []struct {
+		priority Priority
+		want     Color
+	}{
+		{priority: "critical", want: Bold + "\033[38;5;196m"},
+		{priority: "high", want: roleColor("priority_high")},
+		{priority: "normal", want: roleColor("priority_medium")},
+		{priority: "someday", want: roleColor("priority_low")},
+		{priority: "medium", want: Reset},
+	}
+	for _, tt := range tests {
+		if got := priorityColor(tt.priority); got != tt.want {
+			t.Errorf("priorityColor(%q) = %q, want %q", tt.priority, got, tt.want)
+		}
+	}
+
+	err := cmdAdd(addOptions{Title: "Sometime", Priority: "medium"})
+	if err == nil || !strings.Contains(err.Error(), "Use critical, high, normal, low, someday") {
+		t.Errorf("cmdAdd() with a priority off the scale error = %v", err)
+	}
+}
+
+func TestMigrationTarget(t *testing.T) {
+	s := priorityScale{Levels: []Priority{"P0", "P1", "P2", "P3", "P4"}}
+	mapping := map[Priority]Priority{"medium": "P1"}
+
+	tests := []struct {
+		from Priority
+		want Priority
+	}{
+		{from: "high", want: "P0"},
+		{from: "medium", want: "P1"},
+		{from: "low", want: "P4"},
+		{from: "urgent", want: "P3"},
+	}
+	for _, tt := range tests {
+		if got := s.migrationTarget(tt.from, mapping, "P3"); got != tt.want {
+			t.Errorf("migrationTarget(%q) = %q, want %q", tt.from, got, tt.want)
+		}
+	}
+
+	two := priorityScale{Levels: []Priority{"now", "later"}}
+	if got := two.migrationTarget(PriorityMedium, nil, "later"); got != "later" {
+		t.Errorf("migrationTarget(medium) on two levels = %q, want later", got)
+	}
+
+	if _, err := s.parseMapping("high:P9"); err == nil {
+		t.Errorf("parseMapping() should reject targets off the scale")
+	}
+	if _, err := s.parseMapping("high"); err == nil {
+		t.Errorf("parseMapping() should reject entries without a target")
+	}
+}
+
+func TestCmdPrioritiesMigrate(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Fire", PriorityHigh, "", "")
+	insertTestTodo(t, "Chore", PriorityMedium, "", "")
+	insertTestTodo(t, "Idea", PriorityLow, "", "")
+	setScaleEnv(t, "TODO_PRIORITY_LEVELS", "P0,P1,P2,P3")
+
+	out := stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "3 todo(s) have a priority not in priority.levels") {
+		t.Errorf("list should point out priorities off the scale:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() {
+		if err := cmdPrioritiesMigrate("medium:P1", true); err != nil {
+			t.Errorf("cmdPrioritiesMigrate() dry run error = %v", err)
+		}
+	}))
+	if !strings.Contains(out, "medium     → P1") || !strings.Contains(out, "Dry run") {
+		t.Errorf("dry run output:\n%s", out)
+	}
+	if todo, _ := getTodoByID(2); todo.Priority != PriorityMedium {
+		t.Errorf("dry run changed todo #2 to %q", todo.Priority)
+	}
+
+	captureOutput(func() {
+		if err := cmdPrioritiesMigrate("medium:P1", false); err != nil {
+			t.Errorf("cmdPrioritiesMigrate() error = %v", err)
+		}
+	})
+	for id, want := range map[int]Priority{1: "P0", 2: "P1", 3: "P3"} {
+		if todo, _ := getTodoByID(id); todo.Priority != want {
+			t.Errorf("after migrate #%d priority = %q, want %q", id, todo.Priority, want)
+		}
+	}
+
+	out = captureOutput(func() { cmdPrioritiesMigrate("", false) })
+	if !strings.Contains(out, "All todos are on the priority scale") {
+		t.Errorf("second migrate output = %q", out)
+	}
+
+	// The whole migration is one command for undo
+	if _, err := undoJournal(1); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if todo, _ := getTodoByID(1); todo.Priority != PriorityHigh {
+		t.Errorf("after undo #1 priority = %q, want high", todo.Priority)
+	}
+}
+
+func TestLoadConfig_PriorityLevels(t *testing.T) {
+	c, err := loadConfig(writeTestConfig(t, "[defaults]\npriority = \"P1\"\n\n[priority]\nlevels = \"P0,P1,P2\"\n"))
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if got := c.priorityScale().names(); got != "P0, P1, P2" {
+		t.Errorf("priorityScale() = %s", got)
+	}
+	if got := c.priorityScale().choices(); got != "P0|P1|P2" {
+		t.Errorf("priorityScale().choices() = %s", got)
+	}
+
+	_, err = loadConfig(writeTestConfig(t, "[priority]\nlevels = \"P0,P1,P2\"\n\n[defaults]\npriority = \"high\"\n"))
+	if err == nil || !strings.Contains(err.Error(), ":5: defaults.priority: invalid priority: high. Use P0, P1, P2") {
+		t.Errorf("loadConfig() with a default off the scale error = %v", err)
+	}
+
+	if err := c.Set("defaults.priority", "medium"); err == nil {
+		t.Errorf("Set() should reject a default priority off the scale")
+	}
+
+	// A default from the environment is checked against the scale too
+	t.Setenv("TODO_DEFAULTS_PRIORITY", "high")
+	_, err = loadConfig(writeTestConfig(t, "[priority]\nlevels = \"P0,P1,P2\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "TODO_DEFAULTS_PRIORITY: invalid priority: high. Use P0, P1, P2") {
+		t.Errorf("loadConfig() with TODO_DEFAULTS_PRIORITY off the scale error = %v", err)
+	}
+	t.Setenv("TODO_PRIORITY_LEVELS", "P0,P1")
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Errorf("loadConfig() without a file should still check TODO_DEFAULTS_PRIORITY")
+	}
+}
+
+func TestPriorityScaleCache(t *testing.T) {
+	saved := cfg
+	defer func() { cfg = saved }()
+
+	loaded, err := loadConfig(t.TempDir() + "/config")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	cfg = loaded
+
+	if Priority("P0").IsValid() {
+		t.Fatalf("P0 should not be on the default scale")
+	}
+	// The scale is kept between calls until the config changes
+	t.Setenv("TODO_PRIORITY_LEVELS", "P0,P1")
+	if Priority("P0").IsValid() {
+		t.Errorf("IsValid() re-read the scale without a config change")
+	}
+	os.Unsetenv("TODO_PRIORITY_LEVELS")
+
+	if err := cfg.Set("priority.levels", "P0,P1,P2"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if !Priority("P0").IsValid() || Priority("P0").Rank() != 3 {
+		t.Errorf("Set() should reset the cached scale")
+	}
+}
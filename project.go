@@ -0,0 +1,461 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// project groups todos under a name that can be archived as a whole and
+// carries settings of its own.
+type project struct {
+	ID        int
+	Name      string
+	Archived  bool
+	CreatedAt time.Time
+
+	// Settings override the config file while the project is active.
+	Settings map[string]string
+}
+
+// nameRe matches the names of projects and contexts.
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// projectSettingKeys are the settings a project can override.
+var projectSettingKeys = []string{"defaults.priority", "defaults.category", "list.sort", "list.columns", "list.group_by"}
+
+// activeProject is the project commands work in, from --project or
+// defaults.project. Nil means no project: new todos get none and lists
+// show todos of every project that isn't archived.
+var activeProject *project
+
+func validateProjectName(v string) error {
+	if v != "" && !nameRe.MatchString(v) {
+		return fmt.Errorf("invalid project name %q. Use letters, digits, ., - and _", v)
+	}
+	return nil
+}
+
+func createProjectsTables() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		archived INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS project_settings (
+		project_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (project_id, key)
+	)`)
+	return err
+}
+
+func queryProjects(where string, args ...any) ([]project, error) {
+	rows, err := db.Query(`SELECT id, name, archived, created_at FROM projects `+where+` ORDER BY name`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []project
+	for rows.Next() {
+		var p project
+		if err := rows.Scan(&p.ID, &p.Name, &p.Archived, &p.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		projects = append(projects, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		if projects[i].Settings, err = projectSettings(projects[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return projects, nil
+}
+
+func projectSettings(id int) (map[string]string, error) {
+	rows, err := db.Query(`SELECT key, value FROM project_settings WHERE project_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+func getProjects(includeArchived bool) ([]project, error) {
+	if includeArchived {
+		return queryProjects("")
+	}
+	return queryProjects("WHERE archived = 0")
+}
+
+func getProjectByName(name string) (*project, error) {
+	projects, err := queryProjects("WHERE name = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("project %q not found. Use project create to add it", name)
+	}
+	return &projects[0], nil
+}
+
+// projectNames maps every project ID to its name, for displays that name
+// the projects of many todos.
+func projectNames() (map[int]string, error) {
+	rows, err := db.Query(`SELECT id, name FROM projects`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+// projectName names project id for display, or returns "" for no project.
+func projectName(id int) string {
+	if id == 0 {
+		return ""
+	}
+	var name string
+	if err := db.QueryRow(`SELECT name FROM projects WHERE id = ?`, id).Scan(&name); err != nil {
+		return fmt.Sprintf("#%d", id)
+	}
+	return name
+}
+
+// useProject makes the named project the active one and applies its
+// settings. An empty name leaves no project active.
+func useProject(name string) error {
+	cfg.scale = nil
+	if name == "" {
+		activeProject, cfg.project = nil, nil
+		return nil
+	}
+
+	p, err := getProjectByName(name)
+	if err != nil {
+		return err
+	}
+	activeProject, cfg.project = p, p.Settings
+	return nil
+}
+
+// checkNewProjectName checks name is valid for a project and unused.
+func checkNewProjectName(name string) error {
+	if name == "" {
+		return fmt.Errorf("project name can not be empty")
+	}
+	if err := validateProjectName(name); err != nil {
+		return err
+	}
+	if _, err := getProjectByName(name); err == nil {
+		return fmt.Errorf("project %q already exists", name)
+	}
+	return nil
+}
+
+func createProject(name string) (int, error) {
+	if err := checkNewProjectName(name); err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(`INSERT INTO projects (name) VALUES (?)`, name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func setProjectArchived(id int, archived bool) error {
+	_, err := db.Exec(`UPDATE projects SET archived = ? WHERE id = ?`, archived, id)
+	return err
+}
+
+func renameProject(id int, name string) error {
+	_, err := db.Exec(`UPDATE projects SET name = ? WHERE id = ?`, name, id)
+	return err
+}
+
+// setProjectSetting stores a setting of project id. An empty value removes
+// it, so the config file applies again.
+func setProjectSetting(id int, key, value string) error {
+	if value == "" {
+		_, err := db.Exec(`DELETE FROM project_settings WHERE project_id = ? AND key = ?`, id, key)
+		return err
+	}
+	_, err := db.Exec(`
+	INSERT INTO project_settings (project_id, key, value) VALUES (?, ?, ?)
+	ON CONFLICT (project_id, key) DO UPDATE SET value = excluded.value`, id, key, value)
+	return err
+}
+
+// assignProject moves todos into project id, or out of any project for 0,
+// as one command for undo.
+func assignProject(ids []int, id int) error {
+	return withJournal("project assign", func(b *journalBatch) error {
+		for _, todoID := range ids {
+			if err := updateTodoRow(b, todoID, `UPDATE todos SET project_id = ? WHERE id = ?`, id, todoID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// projectCounts counts the pending and done todos of every project by ID.
+func projectCounts() (pending, done map[int]int, err error) {
+	rows, err := db.Query(`SELECT project_id, done, COUNT(*) FROM todos GROUP BY project_id, done`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	pending, done = map[int]int{}, map[int]int{}
+	for rows.Next() {
+		var id, isDone, count int
+		if err := rows.Scan(&id, &isDone, &count); err != nil {
+			return nil, nil, err
+		}
+		if isDone == 1 {
+			done[id] = count
+		} else {
+			pending[id] = count
+		}
+	}
+	return pending, done, rows.Err()
+}
+
+// describeSettings lists settings as key=value in a stable order.
+func describeSettings(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + settings[key]
+	}
+	return strings.Join(parts, ", ")
+}
+
+func cmdProjectCreate(name string) error {
+	if _, err := createProject(name); err != nil {
+		return err
+	}
+	fmt.Printf("%s Created project %s\n", colorize(Green, "✓"), name)
+	return nil
+}
+
+func cmdProjectList(all bool) error {
+	projects, err := getProjects(all)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nProjects:")
+	fmt.Println("---------------------------------------")
+	if len(projects) == 0 {
+		fmt.Println("No projects. Use project create <name> to add one")
+		return nil
+	}
+
+	pending, done, err := projectCounts()
+	if err != nil {
+		return err
+	}
+
+	table := NewTable([]string{"Name", "Pending", "Done", "Settings"})
+	table.SetAlign(AlignRight, "Pending", "Done")
+	table.SetFlexible("Settings")
+	for _, p := range projects {
+		name := p.Name
+		switch {
+		case p.Archived:
+			name = colorize(roleColor("done"), p.Name+" (archived)")
+		case activeProject != nil && activeProject.ID == p.ID:
+			name = colorize(Bold, p.Name+" *")
+		}
+		table.AddRow([]string{name, fmt.Sprintf("%d", pending[p.ID]), fmt.Sprintf("%d", done[p.ID]), describeSettings(p.Settings)})
+	}
+	table.Print()
+
+	if activeProject != nil {
+		fmt.Printf("* active project\n")
+	}
+	return nil
+}
+
+func cmdProjectArchive(name string, archived bool) error {
+	p, err := getProjectByName(name)
+	if err != nil {
+		return err
+	}
+	if p.Archived == archived {
+		state := "archived"
+		if !archived {
+			state = "not archived"
+		}
+		fmt.Printf("Project %s is %s\n", name, state)
+		return nil
+	}
+
+	if err := setProjectArchived(p.ID, archived); err != nil {
+		return err
+	}
+	if !archived {
+		fmt.Printf("%s Restored project %s\n", colorize(Green, "✓"), name)
+		return nil
+	}
+
+	pending, _, err := projectCounts()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Archived project %s", colorize(Green, "✓"), name)
+	if n := pending[p.ID]; n > 0 {
+		fmt.Printf(", hiding %d pending todo(s)", n)
+	}
+	fmt.Println()
+	return nil
+}
+
+func cmdProjectRename(oldName, newName string) error {
+	p, err := getProjectByName(oldName)
+	if err != nil {
+		return err
+	}
+	if err := checkNewProjectName(newName); err != nil {
+		return err
+	}
+
+	if err := renameProject(p.ID, newName); err != nil {
+		return err
+	}
+	fmt.Printf("%s Renamed project %s to %s\n", colorize(Green, "✓"), oldName, newName)
+	if cfg.Get("defaults.project") == oldName {
+		fmt.Printf("defaults.project still names %s. Use config set defaults.project %s to follow the rename\n", oldName, newName)
+	}
+	return nil
+}
+
+// cmdProjectSet changes a project setting; an empty value removes it.
+func cmdProjectSet(name, key, value string) error {
+	p, err := getProjectByName(name)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, k := range projectSettingKeys {
+		allowed = allowed || k == key
+	}
+	if !allowed {
+		return fmt.Errorf("projects can't set %q. Use %s", key, strings.Join(projectSettingKeys, ", "))
+	}
+	if value != "" {
+		if err := cfg.validate(key, value); err != nil {
+			return err
+		}
+	}
+
+	if err := setProjectSetting(p.ID, key, value); err != nil {
+		return err
+	}
+	if value == "" {
+		fmt.Printf("%s Removed %s from project %s\n", colorize(Green, "✓"), key, name)
+		return nil
+	}
+	fmt.Printf("%s Set %s = %q for project %s\n", colorize(Green, "✓"), key, value, name)
+	return nil
+}
+
+// cmdProjectAssign moves todos into the named project, or out of any
+// project when name is empty.
+func cmdProjectAssign(name string, ids []int) error {
+	id := 0
+	if name != "" {
+		p, err := getProjectByName(name)
+		if err != nil {
+			return err
+		}
+		if p.Archived {
+			return fmt.Errorf("project %s is archived. Use project unarchive %s first", name, name)
+		}
+		id = p.ID
+	}
+
+	if err := assignProject(ids, id); err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Printf("%s Removed %d todo(s) from their project\n", colorize(Green, "✓"), len(ids))
+		return nil
+	}
+	fmt.Printf("%s Moved %d todo(s) to project %s\n", colorize(Green, "✓"), len(ids), name)
+	return nil
+}
+
+// projectForNewTodo is the project new todos go into: the active one,
+// unless it is archived.
+func projectForNewTodo() (int, error) {
+	if activeProject == nil {
+		return 0, nil
+	}
+	if activeProject.Archived {
+		return 0, fmt.Errorf("project %s is archived. Use project unarchive %s first", activeProject.Name, activeProject.Name)
+	}
+	return activeProject.ID, nil
+}
+
+// activeProjectID is the ID of the active project, or 0 for none.
+func activeProjectID() int {
+	if activeProject == nil {
+		return 0
+	}
+	return activeProject.ID
+}
+
+// projectHeader names the active project for list headers.
+func projectHeader() string {
+	if activeProject == nil {
+		return ""
+	}
+	return " in " + activeProject.Name
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// enterProject makes name the active project for the rest of the test.
+func enterProject(t *testing.T, name string) {
+	if err := useProject(name); err != nil {
+		t.Fatalf("useProject(%q) error = %v", name, err)
+	}
+	t.Cleanup(func() { useProject("") })
+}
+
+func TestCreateProject(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "website"},
+		{name: "q4-launch_v2.1"},
+		{name: "website", wantErr: true},
+		{name: "", wantErr: true},
+		{name: "-flag", wantErr: true},
+		{name: "two words", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := createProject(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("createProject(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	projects, _ := getProjects(false)
+	if len(projects) != 2 || projects[0].Name != "q4-launch_v2.1" {
+		t.Errorf("getProjects() = %+v", projects)
+	}
+	if _, err := getProjectByName("missing"); err == nil || !strings.Contains(err.Error(), "project create") {
+		t.Errorf("getProjectByName(missing) error = %v", err)
+	}
+}
+
+func TestProjectTodos(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("website")
+	createProject("garden")
+	insertTestTodo(t, "Outside any project", PriorityMedium, "", "")
+
+	enterProject(t, "website")
+	out := stripAnsi(captureOutput(func() {
+		if err := cmdAdd(addOptions{Title: "Fix header", Priority: PriorityHigh}); err != nil {
+			t.Fatalf("cmdAdd() in a project error = %v", err)
+		}
+	}))
+	if !strings.Contains(out, "Added todo #2 in website: Fix header") {
+		t.Errorf("cmdAdd() output = %q", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "Pending Todos in website:") || !strings.Contains(out, "Fix header") || strings.Contains(out, "Outside") {
+		t.Errorf("cmdList() in a project:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdShow(2) }))
+	if !strings.Contains(out, "Project:   website") {
+		t.Errorf("cmdShow() missing the project:\n%s", out)
+	}
+
+	// Without a project every todo shows, until its project is archived
+	useProject("")
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{Columns: "id,title,project"}) }))
+	if !strings.Contains(out, "Outside") || !strings.Contains(out, "website") {
+		t.Errorf("cmdList() without a project:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdProjectArchive("website", true) }))
+	if !strings.Contains(out, "Archived project website, hiding 1 pending todo(s)") {
+		t.Errorf("cmdProjectArchive() output = %q", out)
+	}
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if strings.Contains(out, "Fix header") {
+		t.Errorf("cmdList() should hide todos of archived projects:\n%s", out)
+	}
+
+	// An archived project can still be looked into but not added to
+	enterProject(t, "website")
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "Fix header") {
+		t.Errorf("cmdList() in an archived project:\n%s", out)
+	}
+	if err := cmdAdd(addOptions{Title: "More", Priority: PriorityHigh}); err == nil || !strings.Contains(err.Error(), "unarchive") {
+		t.Errorf("cmdAdd() in an archived project error = %v", err)
+	}
+	if err := cmdProjectAssign("website", []int{1}); err == nil {
+		t.Errorf("cmdProjectAssign() to an archived project should fail")
+	}
+
+	captureOutput(func() { cmdProjectArchive("website", false) })
+	useProject("")
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "Fix header") {
+		t.Errorf("cmdList() after unarchiving:\n%s", out)
+	}
+}
+
+func TestProjectClear(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("website")
+	createProject("garden")
+	insertTestTodo(t, "Fix header", PriorityHigh, "", "")
+	insertTestTodo(t, "Plant tulips", PriorityMedium, "", "")
+	insertTestTodo(t, "Outside any project", PriorityLow, "", "")
+	assignProject([]int{1}, 1)
+	assignProject([]int{2}, 2)
+
+	enterProject(t, "website")
+	out := captureOutput(func() {
+		if err := cmdClear(true, "", true); err != nil {
+			t.Fatalf("cmdClear(--all) in a project error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "Cleared all 1 todos in project website") {
+		t.Errorf("cmdClear(--all) output = %q", out)
+	}
+	for _, id := range []int{2, 3} {
+		if _, err := getTodoByID(id); err != nil {
+			t.Errorf("cmdClear(--all) in website removed #%d", id)
+		}
+	}
+
+	// Without a project, archived projects are left alone
+	useProject("")
+	markTodoAsDone(2)
+	markTodoAsDone(3)
+	captureOutput(func() { cmdProjectArchive("garden", true) })
+	captureOutput(func() { cmdClear(false, "", true) })
+	if _, err := getTodoByID(2); err != nil {
+		t.Errorf("cmdClear() removed a todo of an archived project")
+	}
+	if _, err := getTodoByID(3); err == nil {
+		t.Errorf("cmdClear() kept a done todo outside any project")
+	}
+}
+
+func TestProjectScopesViews(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("a")
+	createProject("b")
+	insertTestTodo(t, "Ship a", PriorityHigh, "", "")
+	insertTestTodo(t, "Ship b", PriorityHigh, "", "")
+	insertTestTodo(t, "Plan a", PriorityLow, "", "")
+	assignProject([]int{1, 3}, 1)
+	assignProject([]int{2}, 2)
+	markTodoAsDone(1)
+	markTodoAsDone(2)
+
+	enterProject(t, "a")
+	out := stripAnsi(captureOutput(func() { cmdAgenda(0) }))
+	if !strings.Contains(out, "Agenda in a:") || !strings.Contains(out, "Plan a") {
+		t.Errorf("cmdAgenda() in a project:\n%s", out)
+	}
+
+	// A bulk filter never reaches into another project
+	captureOutput(func() {
+		if err := cmdBulkDelete(todoSelection{Done: true}, bulkOptions{Force: true}); err != nil {
+			t.Fatalf("cmdBulkDelete() error = %v", err)
+		}
+	})
+	if _, err := getTodoByID(1); err == nil {
+		t.Errorf("cmdBulkDelete(--done) in a kept the done todo of a")
+	}
+	if _, err := getTodoByID(2); err != nil {
+		t.Errorf("cmdBulkDelete(--done) in a deleted the done todo of b")
+	}
+
+	captureOutput(func() { cmdBulkStatus(todoSelection{Pending: true}, true, bulkOptions{Force: true}) })
+	if todo, _ := getTodoByID(3); !todo.Done {
+		t.Errorf("cmdBulkStatus(--pending) in a should mark #3 done")
+	}
+}
+
+func TestProjectAssign(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("garden")
+	insertTestTodo(t, "Plant tulips", PriorityMedium, "", "")
+	insertTestTodo(t, "Mow lawn", PriorityMedium, "", "")
+
+	captureOutput(func() {
+		if err := cmdProjectAssign("garden", []int{1, 2}); err != nil {
+			t.Fatalf("cmdProjectAssign() error = %v", err)
+		}
+	})
+	for _, id := range []int{1, 2} {
+		if todo, _ := getTodoByID(id); projectName(todo.ProjectID) != "garden" {
+			t.Errorf("todo #%d project = %d, want garden", id, todo.ProjectID)
+		}
+	}
+
+	history, _ := getTodoHistory(1)
+	if got := stripAnsi(describeChange(history[len(history)-1])); got != "project: (none) → garden" {
+		t.Errorf("history entry = %q", got)
+	}
+
+	// Moving several todos is one step to undo
+	if _, err := undoJournal(1); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	for _, id := range []int{1, 2} {
+		if todo, _ := getTodoByID(id); todo.ProjectID != 0 {
+			t.Errorf("todo #%d project after undo = %d, want 0", id, todo.ProjectID)
+		}
+	}
+}
+
+func TestProjectColumn(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	garden, _ := createProject("garden")
+	house, _ := createProject("house")
+	insertTestTodo(t, "Plant tulips", PriorityMedium, "", "")
+	insertTestTodo(t, "Fix roof", PriorityHigh, "", "")
+	insertTestTodo(t, "Call mum", PriorityLow, "", "")
+	assignProject([]int{1}, garden)
+	assignProject([]int{2}, house)
+
+	column, _ := lookupColumn("project")
+	todos, _ := getTodos(todoFilter{})
+	value := column.Prepare(todos)
+	want := map[int]string{1: "garden", 2: "house", 3: ""}
+	for _, todo := range todos {
+		if got := value(todo); got != want[todo.ID] {
+			t.Errorf("project column for #%d = %q, want %q", todo.ID, got, want[todo.ID])
+		}
+	}
+}
+
+func TestProjectSettings(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("ops")
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{key: "defaults.priority", value: "high"},
+		{key: "defaults.category", value: "infra"},
+		{key: "defaults.priority", value: "urgent", wantErr: true},
+		{key: "list.sort", value: "sideways", wantErr: true},
+		{key: "db.path", value: "/tmp/other.db", wantErr: true},
+		{key: "no.such", value: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		var err error
+		captureOutput(func() { err = cmdProjectSet("ops", tt.key, tt.value) })
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cmdProjectSet(%s, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+
+	t.Setenv("TODO_DEFAULTS_CATEGORY", "")
+	enterProject(t, "ops")
+	if got, source := cfg.Lookup("defaults.priority"); got != "high" || source != "project" {
+		t.Errorf("Lookup(defaults.priority) = %q from %s, want high from project", got, source)
+	}
+	// The environment still wins over the project
+	if got, source := cfg.Lookup("defaults.category"); got != "" || source != "env" {
+		t.Errorf("Lookup(defaults.category) = %q from %s, want the environment", got, source)
+	}
+
+	captureOutput(func() { cmdProjectSet("ops", "defaults.priority", "") })
+	enterProject(t, "ops")
+	if _, source := cfg.Lookup("defaults.priority"); source == "project" {
+		t.Errorf("defaults.priority should no longer come from the project")
+	}
+
+	out := stripAnsi(captureOutput(func() { cmdProjectList(false) }))
+	if !strings.Contains(out, "ops *") || !strings.Contains(out, "defaults.category=infra") {
+		t.Errorf("cmdProjectList() output:\n%s", out)
+	}
+}
+
+func TestCmdProjectRename(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	createProject("site")
+	createProject("blog")
+	insertTestTodo(t, "Fix header", PriorityMedium, "", "")
+	assignProject([]int{1}, 1)
+
+	if err := cmdProjectRename("site", "blog"); err == nil {
+		t.Errorf("cmdProjectRename() onto an existing name should fail")
+	}
+	if err := cmdProjectRename("missing", "other"); err == nil {
+		t.Errorf("cmdProjectRename() of a missing project should fail")
+	}
+
+	captureOutput(func() {
+		if err := cmdProjectRename("site", "website"); err != nil {
+			t.Fatalf("cmdProjectRename() error = %v", err)
+		}
+	})
+	out := stripAnsi(captureOutput(func() { cmdShow(1) }))
+	if !strings.Contains(out, "Project:   website") {
+		t.Errorf("cmdShow() after rename:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdProjectList(false) }))
+	if strings.Contains(out, "│ site") || !strings.Contains(out, "website") {
+		t.Errorf("cmdProjectList() after rename:\n%s", out)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// quickAdd holds the metadata found inline in a todo title. Empty fields
+// were not given.
+type quickAdd struct {
+	Title    string
+	Priority Priority
+	Category string
+	DueDate  string
+}
+
+// parseQuickAdd pulls "!priority", "#category" and "due:date" tokens out of
+// a title, e.g. "Call bank !high #finance due:fri". Tokens that don't parse
+// as metadata (like "!!!") stay in the title, and a leading backslash keeps a
+// token literal ("\#1" is stored as "#1"). The rest of the title keeps its
+// spacing as typed.
+func parseQuickAdd(input string, today time.Time) (quickAdd, error) {
+	var qa quickAdd
+	var title strings.Builder
+	kept, last := 0, 0
+
+	for _, loc := range tokenRe.FindAllStringIndex(input, -1) {
+		token, word := input[loc[0]:loc[1]], ""
+		switch {
+		case strings.HasPrefix(token, `\#`), strings.HasPrefix(token, `\!`), strings.HasPrefix(token, `\due:`):
+			word = token[1:]
+
+		case strings.HasPrefix(token, "!") && Priority(token[1:]).IsValid():
+			qa.Priority = Priority(token[1:])
+
+		case strings.HasPrefix(token, "#") && len(token) > 1:
+			qa.Category = token[1:]
+
+		case strings.HasPrefix(token, "due:"):
+			due, err := parseRelativeDate(strings.TrimPrefix(token, "due:"), today)
+			if err != nil {
+				return quickAdd{}, err
+			}
+			qa.DueDate = due.Format("2006-01-02")
+
+		default:
+			word = token
+		}
+
+		if word != "" {
+			// A word keeps the space typed before it; the first one keeps
+			// the title's leading space even if tokens came before it
+			gap := input[last:loc[0]]
+			if kept == 0 {
+				gap = input[:len(input)-len(strings.TrimLeftFunc(input, unicode.IsSpace))]
+			}
+			title.WriteString(gap + word)
+			kept++
+		}
+		last = loc[1]
+	}
+
+	if kept > 0 {
+		title.WriteString(input[last:])
+	}
+	qa.Title = title.String()
+	return qa, nil
+}
+
+// tokenRe matches the words of a title between runs of space.
+var tokenRe = regexp.MustCompile(`\S+`)
+
+// parseRelativeDate understands YYYY-MM-DD, "today", "tomorrow", weekday
+// names (the next one, counting today) and day offsets like "3d" or "2w".
+func parseRelativeDate(s string, today time.Time) (time.Time, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	lower := strings.ToLower(s)
+
+	switch lower {
+	case "today":
+		return today, nil
+	case "tomorrow", "tom":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if lower == name || lower == name[:3] {
+			days := (int(wd) - int(today.Weekday()) + 7) % 7
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+
+	if date, err := parseDate(s); err == nil {
+		return date, nil
+	}
+
+	if strings.HasSuffix(lower, "d") || strings.HasSuffix(lower, "w") {
+		if d, err := parseDuration(lower); err == nil {
+			return today.AddDate(0, 0, int(d/(24*time.Hour))), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q. Use YYYY-MM-DD, today, tomorrow, a weekday or an offset like 3d", s)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	// A Wednesday
+	today := time.Date(2026, 10, 14, 9, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    quickAdd
+		wantErr string
+	}{
+		{
+			name:  "plain title",
+			input: "Call bank",
+			want:  quickAdd{Title: "Call bank"},
+		},
+		{
+			name:  "all tokens",
+			input: "Call bank !high #finance due:fri",
+			want:  quickAdd{Title: "Call bank", Priority: PriorityHigh, Category: "finance", DueDate: "2026-10-16"},
+		},
+		{
+			name:  "tokens anywhere",
+			input: "#home Fix !low the sink",
+			want:  quickAdd{Title: "Fix the sink", Priority: PriorityLow, Category: "home"},
+		},
+		{
+			name:  "ISO due date",
+			input: "Taxes due:2026-12-31",
+			want:  quickAdd{Title: "Taxes", DueDate: "2026-12-31"},
+		},
+		{
+			name:  "escaped hash and bang",
+			input: `Fix issue \#12 \!important`,
+			want:  quickAdd{Title: "Fix issue #12 !important"},
+		},
+		{
+			name:  "unknown priority stays in title",
+			input: "Wow !!! !urgent",
+			want:  quickAdd{Title: "Wow !!! !urgent"},
+		},
+		{
+			name:  "bare hash stays in title",
+			input: "Press # key",
+			want:  quickAdd{Title: "Press # key"},
+		},
+		{
+			name:  "last token wins",
+			input: "Task !low !high",
+			want:  quickAdd{Title: "Task", Priority: PriorityHigh},
+		},
+		{
+			name:  "spacing kept as typed",
+			input: "Align  the   columns",
+			want:  quickAdd{Title: "Align  the   columns"},
+		},
+		{
+			name:  "spacing kept around tokens",
+			input: "  #home Fix  !low the  sink due:2026-12-31",
+			want:  quickAdd{Title: "  Fix the  sink", Priority: PriorityLow, Category: "home", DueDate: "2026-12-31"},
+		},
+		{
+			name:    "invalid due date",
+			input:   "Task due:someday",
+			wantErr: "invalid date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuickAdd(tt.input, today)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseQuickAdd(%q) error = %v, want it to contain %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseQuickAdd(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseQuickAdd(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCmdAdd_QuickAdd(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	// A Wednesday
+	setClock(t, time.Date(2026, 10, 14, 9, 30, 0, 0, time.Local))
+	captureOutput(func() { cmdContextDefine("work", "work", PriorityLow) })
+	enterContext(t, "work")
+
+	tests := []struct {
+		name string
+		opts addOptions
+		want Todo
+	}{
+		{
+			name: "inline tokens over the context",
+			opts: addOptions{Title: "Call bank !high #finance due:fri"},
+			want: Todo{Title: "Call bank", Priority: PriorityHigh, Category: "finance"},
+		},
+		{
+			name: "flags over inline tokens",
+			opts: addOptions{Title: "Call bank !high #finance", Priority: PriorityMedium},
+			want: Todo{Title: "Call bank", Priority: PriorityMedium, Category: "finance"},
+		},
+		{
+			name: "context defaults",
+			opts: addOptions{Title: "Plain  title"},
+			want: Todo{Title: "Plain  title", Priority: PriorityLow, Category: "work"},
+		},
+		{
+			name: "no parse",
+			opts: addOptions{Title: "Fix #12 !high", NoParse: true},
+			want: Todo{Title: "Fix #12 !high", Priority: PriorityLow, Category: "work"},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureOutput(func() {
+				if err := cmdAdd(tt.opts); err != nil {
+					t.Fatalf("cmdAdd() error = %v", err)
+				}
+			})
+			got, err := getTodoByID(i + 1)
+			if err != nil {
+				t.Fatalf("getTodoByID() error = %v", err)
+			}
+			if got.Title != tt.want.Title || got.Priority != tt.want.Priority || got.Category != tt.want.Category {
+				t.Errorf("cmdAdd(%+v) added %q %s %q, want %q %s %q", tt.opts, got.Title, got.Priority, got.Category,
+					tt.want.Title, tt.want.Priority, tt.want.Category)
+			}
+		})
+	}
+
+	if todo, _ := getTodoByID(1); !todo.DueDate.Valid || todo.DueDate.Time.Format("2006-01-02") != "2026-10-16" {
+		t.Errorf("cmdAdd() due date = %v, want 2026-10-16", todo.DueDate)
+	}
+	if err := cmdAdd(addOptions{Title: "Task due:someday"}); err == nil {
+		t.Errorf("cmdAdd() with an invalid inline due date should fail")
+	}
+}
+
+func TestParseRelativeDate(t *testing.T) {
+	// A Wednesday
+	today := time.Date(2026, 10, 14, 18, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "today", want: "2026-10-14"},
+		{input: "tomorrow", want: "2026-10-15"},
+		{input: "wed", want: "2026-10-14"},
+		{input: "Thursday", want: "2026-10-15"},
+		{input: "mon", want: "2026-10-19"},
+		{input: "3d", want: "2026-10-17"},
+		{input: "2w", want: "2026-10-28"},
+		{input: "2026-11-01", want: "2026-11-01"},
+		{input: "12h", wantErr: true},
+		{input: "next", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseRelativeDate(tt.input, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRelativeDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.Format("2006-01-02") != tt.want {
+				t.Errorf("parseRelativeDate(%q) = %s, want %s", tt.input, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
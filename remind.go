@@ -0,0 +1,333 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Reminder is one notice that a todo is coming due.
+type Reminder struct {
+	Todo   Todo
+	Offset time.Duration
+	DueAt  time.Time
+}
+
+func (r Reminder) Message() string {
+	return fmt.Sprintf("#%d %s is due in %s (%s)", r.Todo.ID, r.Todo.Title,
+		shortDuration(r.Offset), r.DueAt.Format(cfg.Get("date.format")+" 15:04"))
+}
+
+// Notifier delivers reminders.
+type Notifier interface {
+	Notify(r Reminder) error
+}
+
+// stdoutNotifier prints reminders, for running in a terminal or under a
+// service manager that keeps the output.
+type stdoutNotifier struct {
+	w io.Writer
+}
+
+func (n stdoutNotifier) Notify(r Reminder) error {
+	_, err := fmt.Fprintf(n.w, "%s %s %s\n", clock().Format("15:04"), colorize(roleColor("soon"), "Reminder:"), r.Message())
+	return err
+}
+
+// notifySendNotifier shows a desktop notification through notify-send.
+type notifySendNotifier struct{}
+
+func (notifySendNotifier) Notify(r Reminder) error {
+	urgency := "normal"
+	if s := currentPriorities(); s.rank(r.Todo.Priority) == len(s.Levels) {
+		urgency = "critical"
+	}
+	return exec.Command("notify-send", "--urgency="+urgency, "Todo reminder", r.Message()).Run()
+}
+
+// execNotifier runs a shell command for each reminder, with the details in
+// TODO_* environment variables.
+type execNotifier struct {
+	command string
+}
+
+func (n execNotifier) Notify(r Reminder) error {
+	cmd := exec.Command("sh", "-c", n.command)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("TODO_ID=%d", r.Todo.ID),
+		"TODO_TITLE="+r.Todo.Title,
+		"TODO_PRIORITY="+string(r.Todo.Priority),
+		"TODO_CATEGORY="+r.Todo.Category,
+		"TODO_DUE="+r.DueAt.Format(time.RFC3339),
+		"TODO_OFFSET="+shortDuration(r.Offset),
+		"TODO_MESSAGE="+r.Message(),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+var notifierKinds = []string{"stdout", "notify-send", "exec"}
+
+func newNotifier(kind, command string) (Notifier, error) {
+	switch kind {
+	case "stdout":
+		return stdoutNotifier{w: os.Stdout}, nil
+	case "notify-send":
+		return notifySendNotifier{}, nil
+	case "exec":
+		if command == "" {
+			return nil, fmt.Errorf("the exec notifier needs a command. Set remind.exec")
+		}
+		return execNotifier{command: command}, nil
+	}
+	return nil, fmt.Errorf("invalid notifier %q. Use %s", kind, strings.Join(notifierKinds, ", "))
+}
+
+// shortDuration renders a duration compactly, e.g. 1d, 2h30m, 15m, 30s.
+func shortDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0m"
+	}
+
+	var b strings.Builder
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dd", days)
+		d -= days * 24 * time.Hour
+	}
+	if hours := d / time.Hour; hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+		d -= hours * time.Hour
+	}
+	if minutes := d / time.Minute; minutes > 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+		d -= minutes * time.Minute
+	}
+	if seconds := d / time.Second; seconds > 0 {
+		fmt.Fprintf(&b, "%ds", seconds)
+	}
+	if b.Len() == 0 {
+		return d.String()
+	}
+	return b.String()
+}
+
+// parseOffsets reads a comma-separated list of durations before the due
+// time, sorted from the earliest reminder to the last.
+func parseOffsets(s string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		d, err := parseDuration(field)
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, d)
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("no reminder offsets given, e.g. 1d,1h")
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	return offsets, nil
+}
+
+func validateOffsets(v string) error {
+	_, err := parseOffsets(v)
+	return err
+}
+
+func validateClockTime(v string) error {
+	if _, err := time.Parse("15:04", v); err != nil {
+		return fmt.Errorf("invalid time %q. Use HH:MM", v)
+	}
+	return nil
+}
+
+// dueAt turns a due date into the moment reminders count down to: the
+// remind.due_time on that day, local time.
+func dueAt(due time.Time) time.Time {
+	at, err := time.Parse("15:04", cfg.Get("remind.due_time"))
+	if err != nil {
+		at = time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)
+	}
+	return dueDay(due).Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)
+}
+
+func createRemindersTable() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS reminders (
+		todo_id INTEGER NOT NULL,
+		due_date TEXT NOT NULL,
+		offset_minutes INTEGER NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (todo_id, due_date, offset_minutes)
+	)`)
+	return err
+}
+
+// reminderSent reports whether the reminder for offset has gone out for the
+// todo's current due date. Moving the due date re-arms its reminders.
+func reminderSent(todo Todo, offset time.Duration) (bool, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM reminders WHERE todo_id = ? AND due_date = ? AND offset_minutes = ?`,
+		todo.ID, todo.DueDate.Time.Format("2006-01-02"), int(offset.Minutes()),
+	).Scan(&count)
+	return count > 0, err
+}
+
+func markReminderSent(todo Todo, offset time.Duration, at time.Time) error {
+	_, err := db.Exec(
+		`INSERT OR IGNORE INTO reminders (todo_id, due_date, offset_minutes, sent_at) VALUES (?, ?, ?, ?)`,
+		todo.ID, todo.DueDate.Time.Format("2006-01-02"), int(offset.Minutes()), at.UTC(),
+	)
+	return err
+}
+
+// checkReminders sends the reminders that have come up by now for pending
+// todos whose due time hasn't passed. If several offsets of one todo came
+// up since the last check, only the latest is sent and the rest are marked
+// sent, so a daemon started late doesn't send a burst of stale reminders.
+func checkReminders(now time.Time, offsets []time.Duration, n Notifier) (int, error) {
+	todos, err := getTodos(todoFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var failed []error
+	for _, todo := range todos {
+		if !todo.DueDate.Valid {
+			continue
+		}
+		due := dueAt(todo.DueDate.Time)
+		if !now.Before(due) {
+			continue
+		}
+
+		var pending []time.Duration
+		for _, offset := range offsets {
+			if now.Before(due.Add(-offset)) {
+				continue
+			}
+			done, err := reminderSent(todo, offset)
+			if err != nil {
+				return sent, err
+			}
+			if !done {
+				pending = append(pending, offset)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		// offsets are sorted largest first, so the last one is the latest
+		latest := pending[len(pending)-1]
+		// A reminder that can't be delivered stays unmarked, to be retried
+		if err := n.Notify(Reminder{Todo: todo, Offset: latest, DueAt: due}); err != nil {
+			failed = append(failed, fmt.Errorf("reminder for #%d: %w", todo.ID, err))
+			continue
+		}
+		sent++
+
+		for _, offset := range pending {
+			if err := markReminderSent(todo, offset, now); err != nil {
+				return sent, err
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return sent, &notifyError{errors.Join(failed...)}
+	}
+	return sent, nil
+}
+
+// notifyError reports reminders the notifier failed to deliver. The other
+// reminders were still sent.
+type notifyError struct {
+	err error
+}
+
+func (e *notifyError) Error() string { return e.err.Error() }
+func (e *notifyError) Unwrap() error { return e.err }
+
+// sleep waits for d or until stop fires, and reports whether to keep going.
+// Tests replace it to run the daemon loop without waiting.
+var sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// runReminders checks for reminders every interval until stop fires.
+func runReminders(interval time.Duration, offsets []time.Duration, n Notifier, stop <-chan os.Signal) error {
+	for {
+		// A failed delivery, or a database another command keeps busy, is
+		// logged and retried on the next check, so the daemon keeps going
+		_, err := checkReminders(clock(), offsets, n)
+		var failed *notifyError
+		if errors.As(err, &failed) || isBusy(err) {
+			fmt.Println("Error:", err)
+		} else if err != nil {
+			return err
+		}
+		if !sleep(interval, stop) {
+			return nil
+		}
+	}
+}
+
+func cmdRemind(daemon bool, interval, notifier string) error {
+	offsets, err := parseOffsets(cfg.Get("remind.offsets"))
+	if err != nil {
+		return err
+	}
+
+	n, err := newNotifier(notifier, cfg.Get("remind.exec"))
+	if err != nil {
+		return err
+	}
+
+	if !daemon {
+		sent, err := checkReminders(clock(), offsets, n)
+		if err != nil {
+			return err
+		}
+		if sent == 0 {
+			fmt.Println("No reminders due")
+		}
+		return nil
+	}
+
+	every, err := parseDuration(interval)
+	if err != nil {
+		return err
+	}
+	if every <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	fmt.Printf("Checking for reminders every %s (offsets %s). Press Ctrl-C to stop.\n", shortDuration(every), cfg.Get("remind.offsets"))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	return runReminders(every, offsets, n, stop)
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNotifier records reminders instead of delivering them.
+type fakeNotifier struct {
+	sent []Reminder
+}
+
+func (n *fakeNotifier) Notify(r Reminder) error {
+	n.sent = append(n.sent, r)
+	return nil
+}
+
+// failingNotifier fails the first failures deliveries, then records the
+// rest.
+type failingNotifier struct {
+	failures int
+	fakeNotifier
+}
+
+func (n *failingNotifier) Notify(r Reminder) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("notify-send: exit status 1")
+	}
+	return n.fakeNotifier.Notify(r)
+}
+
+func TestParseOffsets(t *testing.T) {
+	got, err := parseOffsets("1h, 1d,30m")
+	if err != nil {
+		t.Fatalf("parseOffsets() error = %v", err)
+	}
+	want := []time.Duration{24 * time.Hour, time.Hour, 30 * time.Minute}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("parseOffsets() = %v, want %v", got, want)
+		}
+	}
+
+	for _, bad := range []string{"", "soon", "1d,-1h"} {
+		if _, err := parseOffsets(bad); err == nil {
+			t.Errorf("parseOffsets(%q) should fail", bad)
+		}
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{24 * time.Hour, "1d"},
+		{150 * time.Minute, "2h30m"},
+		{26 * time.Hour, "1d2h"},
+		{15 * time.Minute, "15m"},
+		{30 * time.Second, "30s"},
+		{90 * time.Second, "1m30s"},
+		{500 * time.Millisecond, "500ms"},
+		{0, "0m"},
+	}
+
+	for _, tt := range tests {
+		if got := shortDuration(tt.d); got != tt.want {
+			t.Errorf("shortDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReminders(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	offsets := []time.Duration{24 * time.Hour, time.Hour}
+	id := int(insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01"))
+	insertTestTodo(t, "No date", PriorityHigh, "", "")
+	done := insertTestTodo(t, "Finished", PriorityHigh, "", "2026-11-01")
+	markTodoAsDone(int(done))
+
+	// Due dates fall due at remind.due_time, 09:00 by default
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+
+	steps := []struct {
+		name       string
+		now        time.Time
+		wantOffset time.Duration
+	}{
+		{name: "two days before", now: at(30, 9, 0)},
+		{name: "one day before", now: at(31, 9, 0), wantOffset: 24 * time.Hour},
+		{name: "one day before, again", now: at(31, 10, 0)},
+		{name: "one hour before", now: time.Date(2026, 11, 1, 8, 0, 0, 0, time.Local), wantOffset: time.Hour},
+		{name: "one hour before, again", now: time.Date(2026, 11, 1, 8, 30, 0, 0, time.Local)},
+		{name: "after the deadline", now: time.Date(2026, 11, 1, 9, 30, 0, 0, time.Local)},
+	}
+
+	for _, step := range steps {
+		n := &fakeNotifier{}
+		count, err := checkReminders(step.now, offsets, n)
+		if err != nil {
+			t.Fatalf("%s: checkReminders() error = %v", step.name, err)
+		}
+
+		if step.wantOffset == 0 {
+			if count != 0 || len(n.sent) != 0 {
+				t.Errorf("%s: sent %d reminders, want none", step.name, len(n.sent))
+			}
+			continue
+		}
+		if count != 1 || len(n.sent) != 1 {
+			t.Fatalf("%s: sent %d reminders, want 1", step.name, len(n.sent))
+		}
+		r := n.sent[0]
+		if r.Todo.ID != id || r.Offset != step.wantOffset {
+			t.Errorf("%s: sent %+v, want #%d at %v", step.name, r, id, step.wantOffset)
+		}
+		if !strings.Contains(r.Message(), "Pay rent is due in "+shortDuration(step.wantOffset)) {
+			t.Errorf("%s: message = %q", step.name, r.Message())
+		}
+	}
+}
+
+func TestCheckReminders_LateStartSendsLatestOnly(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	offsets := []time.Duration{24 * time.Hour, time.Hour}
+	insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01")
+
+	n := &fakeNotifier{}
+	now := time.Date(2026, 11, 1, 8, 15, 0, 0, time.Local)
+	if _, err := checkReminders(now, offsets, n); err != nil {
+		t.Fatalf("checkReminders() error = %v", err)
+	}
+	if len(n.sent) != 1 || n.sent[0].Offset != time.Hour {
+		t.Fatalf("checkReminders() sent %+v, want only the 1h reminder", n.sent)
+	}
+
+	// The skipped 1d reminder counts as sent
+	n = &fakeNotifier{}
+	checkReminders(now.Add(10*time.Minute), offsets, n)
+	if len(n.sent) != 0 {
+		t.Errorf("checkReminders() sent %+v again", n.sent)
+	}
+}
+
+func TestCheckReminders_NewDueDateRearms(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	offsets := []time.Duration{24 * time.Hour}
+	id := int(insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01"))
+
+	n := &fakeNotifier{}
+	checkReminders(time.Date(2026, 10, 31, 12, 0, 0, 0, time.Local), offsets, n)
+	if err := updateTodo(id, "", "", "", "2026-11-08"); err != nil {
+		t.Fatalf("updateTodo() error = %v", err)
+	}
+	checkReminders(time.Date(2026, 11, 7, 12, 0, 0, 0, time.Local), offsets, n)
+
+	if len(n.sent) != 2 {
+		t.Errorf("checkReminders() sent %d reminders, want one per due date", len(n.sent))
+	}
+}
+
+func TestRunReminders(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01")
+
+	// A fake clock that advances by the interval on every sleep
+	now := time.Date(2026, 10, 31, 7, 0, 0, 0, time.Local)
+	setClock(t, now)
+	savedSleep := sleep
+	defer func() { sleep = savedSleep }()
+
+	sleeps := 0
+	sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+		sleeps++
+		now = now.Add(d)
+		clock = func() time.Time { return now }
+		return sleeps < 30
+	}
+
+	n := &fakeNotifier{}
+	err := runReminders(time.Hour, []time.Duration{24 * time.Hour, time.Hour}, n, nil)
+	if err != nil {
+		t.Fatalf("runReminders() error = %v", err)
+	}
+
+	if len(n.sent) != 2 || n.sent[0].Offset != 24*time.Hour || n.sent[1].Offset != time.Hour {
+		t.Errorf("runReminders() sent %+v, want the 1d and then the 1h reminder", n.sent)
+	}
+}
+
+func TestRunReminders_NotifierFailureRetries(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01")
+	insertTestTodo(t, "Call mum", PriorityLow, "", "2026-11-01")
+
+	now := time.Date(2026, 10, 31, 12, 0, 0, 0, time.Local)
+	setClock(t, now)
+	savedSleep := sleep
+	defer func() { sleep = savedSleep }()
+
+	sleeps := 0
+	sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+		sleeps++
+		now = now.Add(d)
+		clock = func() time.Time { return now }
+		return sleeps < 2
+	}
+
+	// The first delivery fails; the daemon keeps going, sends the other
+	// todo's reminder and retries the failed one on the next check
+	n := &failingNotifier{failures: 1}
+	var err error
+	out := captureOutput(func() {
+		err = runReminders(time.Minute, []time.Duration{24 * time.Hour}, n, nil)
+	})
+	if err != nil {
+		t.Fatalf("runReminders() error = %v, want it to keep running", err)
+	}
+	if !strings.Contains(out, "Error: reminder for #1: notify-send: exit status 1") {
+		t.Errorf("runReminders() should log the failure, output = %q", out)
+	}
+
+	var titles []string
+	for _, r := range n.sent {
+		titles = append(titles, r.Todo.Title)
+	}
+	if strings.Join(titles, ",") != "Call mum,Pay rent" {
+		t.Errorf("runReminders() sent %v, want Call mum and then the retried Pay rent", titles)
+	}
+
+	// A single check still reports the failure
+	insertTestTodo(t, "Renew passport", PriorityLow, "", "2026-11-02")
+	_, err = checkReminders(now.Add(24*time.Hour), []time.Duration{24 * time.Hour}, &failingNotifier{failures: 1})
+	if err == nil || !strings.Contains(err.Error(), "reminder for #3") {
+		t.Errorf("checkReminders() with a failing notifier error = %v", err)
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		kind    string
+		command string
+		wantErr bool
+	}{
+		{kind: "stdout"},
+		{kind: "notify-send"},
+		{kind: "exec", command: "true"},
+		{kind: "exec", wantErr: true},
+		{kind: "email", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := newNotifier(tt.kind, tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newNotifier(%q, %q) error = %v, wantErr %v", tt.kind, tt.command, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExecNotifier(t *testing.T) {
+	out := t.TempDir() + "/reminder"
+	n := execNotifier{command: `echo "$TODO_ID|$TODO_TITLE|$TODO_OFFSET" > ` + out}
+
+	r := Reminder{Todo: Todo{ID: 7, Title: "Pay rent"}, Offset: time.Hour, DueAt: time.Now()}
+	if err := n.Notify(r); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if strings.TrimSpace(string(got)) != "7|Pay rent|1h" {
+		t.Errorf("hook saw %q, want %q", got, "7|Pay rent|1h")
+	}
+}
+
+func TestRunReminders_BusyDatabaseRetries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.db")
+	var err error
+	if db, err = sql.Open("sqlite3", path+"?_busy_timeout=0"); err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer teardownTestDB()
+	if err := createTables(); err != nil {
+		t.Fatalf("createTables() error = %v", err)
+	}
+	insertTestTodo(t, "Pay rent", PriorityHigh, "", "2026-11-01")
+
+	// Another command holds the database while the first check runs
+	other, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer other.Close()
+	ctx := context.Background()
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatalf("BEGIN EXCLUSIVE error = %v", err)
+	}
+
+	now := time.Date(2026, 10, 31, 12, 0, 0, 0, time.Local)
+	setClock(t, now)
+	savedSleep := sleep
+	defer func() { sleep = savedSleep }()
+
+	sleeps := 0
+	sleep = func(d time.Duration, stop <-chan os.Signal) bool {
+		sleeps++
+		if sleeps == 1 {
+			conn.ExecContext(ctx, "COMMIT")
+		}
+		return sleeps < 2
+	}
+
+	n := &fakeNotifier{}
+	out := captureOutput(func() {
+		err = runReminders(time.Minute, []time.Duration{24 * time.Hour}, n, nil)
+	})
+	if err != nil {
+		t.Fatalf("runReminders() error = %v, want it to keep running", err)
+	}
+	if !strings.Contains(out, "Error: database is locked") {
+		t.Errorf("runReminders() should log the busy database, output = %q", out)
+	}
+	if len(n.sent) != 1 {
+		t.Errorf("runReminders() sent %d reminders after the lock was released, want 1", len(n.sent))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+var reportFormats = []string{"markdown", "html"}
+
+// report is a status document: every pending todo plus, if Since is set,
+// the todos completed since then, grouped by category.
+type report struct {
+	Generated time.Time
+	Since     time.Time
+	Groups    []todoGroup
+}
+
+// buildReport collects the todos for a report. A zero since leaves
+// completed todos out.
+func buildReport(now, since time.Time) (*report, error) {
+	todos, err := getTodos(todoFilter{ShowAll: true, Snoozed: true, Project: activeProjectID()})
+	if err != nil {
+		return nil, err
+	}
+
+	var included []Todo
+	for _, todo := range todos {
+		if !todo.Done {
+			included = append(included, todo)
+		}
+	}
+	if !since.IsZero() {
+		included = append(included, completedAfter(todos, since)...)
+	}
+
+	groups, err := groupTodos(included, "category")
+	if err != nil {
+		return nil, err
+	}
+
+	return &report{Generated: now, Since: since, Groups: groups}, nil
+}
+
+// isOverdue reports whether a pending todo was due before today.
+func (r *report) isOverdue(todo Todo) bool {
+	if todo.Done || !todo.DueDate.Valid {
+		return false
+	}
+	y, m, d := r.Generated.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return todo.DueDate.Time.Before(today)
+}
+
+// details lists the due and completion dates of a todo.
+func (r *report) details(todo Todo) []string {
+	layout := cfg.Get("date.format")
+
+	var details []string
+	if todo.DueDate.Valid {
+		due := "due " + todo.DueDate.Time.Format(layout)
+		if r.isOverdue(todo) {
+			due = "overdue, " + due
+		}
+		details = append(details, due)
+	}
+	if todo.Done && todo.CompletedAt.Valid {
+		details = append(details, "completed "+todo.CompletedAt.Time.Local().Format(layout))
+	}
+	return details
+}
+
+func (r *report) title() string {
+	return "Todo report " + r.Generated.Format(cfg.Get("date.format"))
+}
+
+func (r *report) summary() string {
+	open, done := 0, 0
+	for _, g := range r.Groups {
+		for _, todo := range g.Todos {
+			if todo.Done {
+				done++
+			} else {
+				open++
+			}
+		}
+	}
+
+	s := fmt.Sprintf("%d open", open)
+	if !r.Since.IsZero() {
+		s += fmt.Sprintf(", %d completed since %s", done, r.Since.Format(cfg.Get("date.format")))
+	}
+	return s
+}
+
+// Markdown renders the report with GitHub-style task list items.
+func (r *report) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", r.title())
+	fmt.Fprintf(&b, "_%s_\n", r.summary())
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "\n## %s\n\n", g.Title)
+		for _, todo := range g.Todos {
+			check := " "
+			if todo.Done {
+				check = "x"
+			}
+
+			line := fmt.Sprintf("- [%s] %s `%s`", check, todo.Title, todo.Priority)
+			if details := r.details(todo); len(details) > 0 {
+				line += " — " + strings.Join(details, ", ")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if len(r.Groups) == 0 {
+		b.WriteString("\nNothing to report.\n")
+	}
+
+	return b.String()
+}
+
+const reportCSS = `
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48em; margin: 2em auto; padding: 0 1em; color: #24292f; }
+h1 { font-size: 1.6em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; }
+.summary { color: #57606a; font-style: italic; }
+ul { list-style: none; padding-left: 0; }
+li { margin: .35em 0; }
+li.done .title { color: #57606a; text-decoration: line-through; }
+.badge { display: inline-block; padding: 0 .5em; border-radius: 1em; font-size: .8em; color: #fff; background: #6e7781; }
+.details { color: #57606a; font-size: .9em; }
+.overdue { color: #cf222e; font-weight: bold; }
+`
+
+// badgeBackgrounds are the badge colors of the priority theme roles.
+var badgeBackgrounds = map[string]string{
+	"priority_high":   "#cf222e",
+	"priority_medium": "#bf8700",
+	"priority_low":    "#1a7f37",
+}
+
+// priorityCSS gives every level of the scale a badge color by its theme
+// role, as in the terminal. Priorities off the scale keep the gray badge.
+func priorityCSS() string {
+	var b strings.Builder
+	s := currentPriorities()
+	for _, level := range s.Levels {
+		fmt.Fprintf(&b, ".priority-%s { background: %s; }\n", level, badgeBackgrounds[s.role(level)])
+	}
+	return b.String()
+}
+
+// HTML renders the report as a standalone page with its styles inline.
+func (r *report) HTML() string {
+	var b strings.Builder
+	title := html.EscapeString(r.title())
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>%s%s</style>\n</head>\n<body>\n", title, reportCSS, priorityCSS())
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", title)
+	fmt.Fprintf(&b, "<p class=\"summary\">%s</p>\n", html.EscapeString(r.summary()))
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n<ul>\n", html.EscapeString(g.Title))
+		for _, todo := range g.Todos {
+			class, checked := "pending", ""
+			if todo.Done {
+				class, checked = "done", " checked"
+			}
+
+			fmt.Fprintf(&b, "<li class=\"%s\"><input type=\"checkbox\" disabled%s> ", class, checked)
+			fmt.Fprintf(&b, "<span class=\"title\">%s</span> ", html.EscapeString(todo.Title))
+			fmt.Fprintf(&b, "<span class=\"badge priority-%s\">%s</span>",
+				html.EscapeString(string(todo.Priority)), html.EscapeString(string(todo.Priority)))
+
+			if details := r.details(todo); len(details) > 0 {
+				class := "details"
+				if r.isOverdue(todo) {
+					class += " overdue"
+				}
+				fmt.Fprintf(&b, " <span class=\"%s\">%s</span>", class, html.EscapeString(strings.Join(details, ", ")))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+
+	if len(r.Groups) == 0 {
+		b.WriteString("<p>Nothing to report.</p>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func cmdReport(format, since string) error {
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		sinceTime, err = parseSince(since)
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := buildReport(clock(), sinceTime)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "markdown", "md":
+		fmt.Print(r.Markdown())
+	case "html":
+		fmt.Print(r.HTML())
+	default:
+		return fmt.Errorf("invalid format %q. Use %s", format, strings.Join(reportFormats, " or "))
+	}
+	return nil
+}
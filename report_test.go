@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildReport(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Write report", PriorityHigh, "work", "2020-01-01")
+	shipped := insertTestTodo(t, "Ship release", PriorityMedium, "work", "")
+	insertTestTodo(t, "Buy milk", PriorityLow, "", "")
+	old := insertTestTodo(t, "Old chore", PriorityLow, "home", "")
+
+	if err := markTodoAsDone(int(old)); err != nil {
+		t.Fatalf("markTodoAsDone() error = %v", err)
+	}
+	if _, err := db.Exec(`UPDATE todos SET completed_at = ? WHERE id = ?`, time.Now().AddDate(0, -1, 0).UTC(), old); err != nil {
+		t.Fatalf("failed to age completion: %v", err)
+	}
+	if err := markTodoAsDone(int(shipped)); err != nil {
+		t.Fatalf("markTodoAsDone() error = %v", err)
+	}
+
+	t.Run("pending only without since", func(t *testing.T) {
+		r, err := buildReport(time.Now(), time.Time{})
+		if err != nil {
+			t.Fatalf("buildReport() error = %v", err)
+		}
+
+		md := r.Markdown()
+		if strings.Contains(md, "Ship release") || strings.Contains(md, "Old chore") {
+			t.Errorf("Markdown() includes completed todos without --since:\n%s", md)
+		}
+		if !strings.Contains(md, "- [ ] Write report `high` — overdue, due 2020-01-01") {
+			t.Errorf("Markdown() missing pending item:\n%s", md)
+		}
+		if strings.Index(md, "## work") > strings.Index(md, "## (none)") {
+			t.Errorf("Markdown() uncategorized section should come last:\n%s", md)
+		}
+	})
+
+	t.Run("completed within window", func(t *testing.T) {
+		r, err := buildReport(time.Now(), time.Now().AddDate(0, 0, -7))
+		if err != nil {
+			t.Fatalf("buildReport() error = %v", err)
+		}
+
+		md := r.Markdown()
+		if !strings.Contains(md, "- [x] Ship release `medium` — completed") {
+			t.Errorf("Markdown() missing completed item:\n%s", md)
+		}
+		if strings.Contains(md, "Old chore") {
+			t.Errorf("Markdown() includes todo completed before the window:\n%s", md)
+		}
+		if !strings.Contains(md, "2 open, 1 completed since") {
+			t.Errorf("Markdown() summary wrong:\n%s", md)
+		}
+	})
+}
+
+func TestReportHTML(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	insertTestTodo(t, "Fix <script> & escaping", PriorityHigh, "web", "")
+
+	r, err := buildReport(time.Now(), time.Time{})
+	if err != nil {
+		t.Fatalf("buildReport() error = %v", err)
+	}
+
+	page := r.HTML()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"<h2>web</h2>",
+		"Fix &lt;script&gt; &amp; escaping",
+		`<span class="badge priority-high">high</span>`,
+		`<input type="checkbox" disabled>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML() missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<link") || strings.Contains(page, "src=") {
+		t.Errorf("HTML() should not reference external resources")
+	}
+}
+
+func TestPriorityCSS(t *testing.T) {
+	setScaleEnv(t, "TODO_PRIORITY_LEVELS", "P0,P1,P2,P3")
+
+	css := priorityCSS()
+	for _, want := range []string{
+		".priority-P0 { background: #cf222e; }",
+		".priority-P1 { background: #bf8700; }",
+		".priority-P2 { background: #bf8700; }",
+		".priority-P3 { background: #1a7f37; }",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("priorityCSS() missing %q:\n%s", want, css)
+		}
+	}
+	if !strings.Contains(reportCSS, ".badge {") || !strings.Contains(reportCSS, "background: #6e7781") {
+		t.Errorf("badges off the scale need a background of their own")
+	}
+}
+
+func TestCmdReport(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	tests := []struct {
+		name    string
+		format  string
+		since   string
+		wantErr string
+	}{
+		{name: "markdown", format: "markdown"},
+		{name: "html", format: "html", since: "7d"},
+		{name: "bad format", format: "pdf", wantErr: "invalid format"},
+		{name: "bad since", format: "markdown", since: "soon", wantErr: "invalid duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			captureOutput(func() { err = cmdReport(tt.format, tt.since) })
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("cmdReport() unexpected error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("cmdReport() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseSnooze reads how long to snooze for: a duration such as 4h or 90m
+// counts from now, and a date as accepted by due: (3d, monday, 2026-11-02)
+// means the start of that day. A weekday is the next one after today,
+// since snoozing until this morning would do nothing.
+func parseSnooze(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("snooze for a positive duration, e.g. 4h")
+		}
+		return now.Add(d), nil
+	}
+
+	date, err := parseRelativeDate(s, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snooze %q. Use a duration (4h, 3d, 2w), a weekday or YYYY-MM-DD", s)
+	}
+	until := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location())
+
+	today := strings.ToLower(now.Weekday().String())
+	if lower := strings.ToLower(s); !until.After(now) && (lower == today || lower == today[:3]) {
+		until = until.AddDate(0, 0, 7)
+	}
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("%s is not in the future", s)
+	}
+	return until, nil
+}
+
+// isSnoozed reports whether todo is pending and hidden until later than now.
+func isSnoozed(todo Todo, now time.Time) bool {
+	return !todo.Done && todo.WaitUntil.Valid && todo.WaitUntil.Time.After(now)
+}
+
+// formatSnooze shows when a snooze ends, leaving out the time of day when
+// it ends at midnight.
+func formatSnooze(t time.Time) string {
+	t = t.Local()
+	if t.Equal(startOfDay(t)) {
+		return t.Format(cfg.Get("date.format"))
+	}
+	return t.Format(cfg.Get("date.format") + " 15:04")
+}
+
+func cmdSnooze(id int, when string) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+	if todo.Done {
+		return fmt.Errorf("todo #%d is done. Use undone to reopen it first", id)
+	}
+
+	until, err := parseSnooze(when, clock())
+	if err != nil {
+		return err
+	}
+
+	if err := snoozeTodo(id, until); err != nil {
+		return err
+	}
+	fmt.Printf("%s Snoozed todo #%d until %s\n", colorize(Green, "✓"), id, formatSnooze(until))
+	return nil
+}
+
+func cmdUnsnooze(id int) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+	if !isSnoozed(*todo, clock()) {
+		fmt.Printf("Todo #%d is not snoozed\n", id)
+		return nil
+	}
+
+	if err := snoozeTodo(id, time.Time{}); err != nil {
+		return err
+	}
+	fmt.Printf("%s Todo #%d is back on the list\n", colorize(Green, "✓"), id)
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSnooze(t *testing.T) {
+	// Monday afternoon
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.Local)
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.Local) }
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "4h", want: now.Add(4 * time.Hour)},
+		{input: "90m", want: now.Add(90 * time.Minute)},
+		{input: "3d", want: day(22)},
+		{input: "1w", want: day(26)},
+		{input: "tomorrow", want: day(20)},
+		{input: "friday", want: day(23)},
+		{input: "monday", want: day(26)},
+		{input: "Mon", want: day(26)},
+		{input: "2026-11-02", want: day(33)},
+		{input: "today", wantErr: true},
+		{input: "2026-10-01", wantErr: true},
+		{input: "-2h", wantErr: true},
+		{input: "later", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSnooze(tt.input, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSnooze(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseSnooze(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSnoozeTodo(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.Local)
+	setClock(t, now)
+
+	id := int(insertTestTodo(t, "Renew passport", PriorityMedium, "", ""))
+	insertTestTodo(t, "Buy milk", PriorityMedium, "", "")
+
+	pending := func() []string {
+		todos, err := getTodos(todoFilter{})
+		if err != nil {
+			t.Fatalf("getTodos() error = %v", err)
+		}
+		var titles []string
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+		}
+		return titles
+	}
+
+	if err := snoozeTodo(id, now.AddDate(0, 0, 3)); err != nil {
+		t.Fatalf("snoozeTodo() error = %v", err)
+	}
+	if got := pending(); len(got) != 1 || got[0] != "Buy milk" {
+		t.Errorf("pending while snoozed = %v, want only Buy milk", got)
+	}
+
+	all, err := getTodos(todoFilter{Snoozed: true})
+	if err != nil || len(all) != 2 {
+		t.Errorf("getTodos() with snoozed = %d todos, %v, want 2", len(all), err)
+	}
+
+	// Once the date passes the todo is back without anything changing
+	setClock(t, now.AddDate(0, 0, 3))
+	if got := pending(); len(got) != 2 {
+		t.Errorf("pending after the snooze = %v, want both todos", got)
+	}
+
+	// Snoozing is journaled and shows in history
+	setClock(t, now)
+	if _, err := undoJournal(1); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if got := pending(); len(got) != 2 {
+		t.Errorf("pending after undo = %v, want both todos", got)
+	}
+
+	history, err := getTodoHistory(id)
+	if err != nil {
+		t.Fatalf("getTodoHistory() error = %v", err)
+	}
+	found := false
+	for _, h := range history {
+		if h.Field == "wait_until" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("history has no wait_until change: %+v", history)
+	}
+}
+
+func TestCmdSnooze(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	setClock(t, time.Date(2026, 10, 19, 15, 30, 0, 0, time.Local))
+
+	insertTestTodo(t, "Renew passport", PriorityMedium, "", "")
+	insertTestTodo(t, "Buy milk", PriorityMedium, "", "")
+	done := insertTestTodo(t, "Finished", PriorityMedium, "", "")
+	markTodoAsDone(int(done))
+
+	out := captureOutput(func() {
+		if err := cmdSnooze(1, "friday"); err != nil {
+			t.Errorf("cmdSnooze() error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "Snoozed todo #1 until 2026-10-23") {
+		t.Errorf("cmdSnooze() output = %q", out)
+	}
+
+	if err := cmdSnooze(int(done), "1d"); err == nil || !strings.Contains(err.Error(), "is done") {
+		t.Errorf("cmdSnooze() on a done todo error = %v", err)
+	}
+	if err := cmdSnooze(1, "yesterday"); err == nil {
+		t.Errorf("cmdSnooze() with an invalid date should fail")
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if strings.Contains(out, "Renew passport") || !strings.Contains(out, "1 snoozed todo(s) hidden") {
+		t.Errorf("list should hide the snoozed todo:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{Snoozed: true}) }))
+	if !strings.Contains(out, "Snoozed Todos:") || !strings.Contains(out, "Renew passport") ||
+		strings.Contains(out, "Buy milk") || !strings.Contains(out, "Snoozed Until") {
+		t.Errorf("list --snoozed output:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdShow(1) }))
+	if !strings.Contains(out, "Snoozed:   until 2026-10-23") {
+		t.Errorf("show should mention the snooze:\n%s", out)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdUnsnooze(1); err != nil {
+			t.Errorf("cmdUnsnooze() error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "back on the list") {
+		t.Errorf("cmdUnsnooze() output = %q", out)
+	}
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{}) }))
+	if !strings.Contains(out, "Renew passport") || strings.Contains(out, "snoozed") {
+		t.Errorf("list after unsnooze:\n%s", out)
+	}
+}
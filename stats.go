@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats summarizes the todo list. The json tags define the output of
+// stats --format json.
+type Stats struct {
+	Total          int            `json:"total"`
+	Pending        int            `json:"pending"`
+	Done           int            `json:"done"`
+	Overdue        int            `json:"overdue"`
+	CompletionRate float64        `json:"completion_rate"`
+	ByPriority     map[string]int `json:"by_priority"`
+	ByCategory     map[string]int `json:"by_category"`
+
+	// AvgCompletion is the mean time from creation to completion over the
+	// todos that have a completion time.
+	AvgCompletion time.Duration `json:"-"`
+	AvgHours      float64       `json:"avg_completion_hours"`
+
+	Daily  []periodCount `json:"completed_per_day"`
+	Weekly []periodCount `json:"completed_per_week"`
+}
+
+// periodCount is the number of todos completed in the day or week starting
+// on Start.
+type periodCount struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+// computeStats works out the statistics for todos as of now, with
+// completion counts for the last days days and weeks weeks.
+func computeStats(todos []Todo, now time.Time, days, weeks int) Stats {
+	s := Stats{
+		ByPriority: map[string]int{},
+		ByCategory: map[string]int{},
+	}
+
+	today := startOfDay(now)
+	monday := startOfWeek(today)
+
+	s.Daily = make([]periodCount, days)
+	for i := range s.Daily {
+		s.Daily[i].Start = today.AddDate(0, 0, i-days+1).Format("2006-01-02")
+	}
+	s.Weekly = make([]periodCount, weeks)
+	for i := range s.Weekly {
+		s.Weekly[i].Start = monday.AddDate(0, 0, 7*(i-weeks+1)).Format("2006-01-02")
+	}
+
+	var spent time.Duration
+	var timed int
+	for _, todo := range todos {
+		s.Total++
+		s.ByPriority[string(todo.Priority)]++
+
+		category := todo.Category
+		if category == "" {
+			category = "(none)"
+		}
+		s.ByCategory[category]++
+
+		if !todo.Done {
+			s.Pending++
+			if todo.DueDate.Valid && dueDay(todo.DueDate.Time).Before(today) {
+				s.Overdue++
+			}
+			continue
+		}
+
+		s.Done++
+		if !todo.CompletedAt.Valid {
+			continue
+		}
+
+		completed := todo.CompletedAt.Time.In(now.Location())
+		if d := completed.Sub(todo.CreatedAt); d > 0 {
+			spent += d
+		}
+		timed++
+
+		day := startOfDay(completed)
+		if day.After(today) {
+			continue
+		}
+		if i := days - 1 - daysBetween(day, today); i >= 0 {
+			s.Daily[i].Count++
+		}
+		if i := weeks - 1 - daysBetween(startOfWeek(day), monday)/7; i >= 0 {
+			s.Weekly[i].Count++
+		}
+	}
+
+	if s.Total > 0 {
+		s.CompletionRate = float64(s.Done) / float64(s.Total)
+	}
+	if timed > 0 {
+		s.AvgCompletion = spent / time.Duration(timed)
+		s.AvgHours = float64(int(s.AvgCompletion.Hours()*10+0.5)) / 10
+	}
+
+	return s
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns midnight on the Monday of t's week.
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// daysBetween counts calendar days from a to b, both at midnight. Rounding
+// absorbs days that are 23 or 25 hours long around DST changes.
+func daysBetween(a, b time.Time) int {
+	return int(math.Round(b.Sub(a).Hours() / 24))
+}
+
+// dueDay turns a stored due date, which is midnight UTC, into midnight
+// local time on the same calendar date.
+func dueDay(due time.Time) time.Time {
+	y, m, d := due.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// formatDuration renders a duration in its two largest units: 2d 4h,
+// 3h 12m, 45m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d%(24*time.Hour)) / int(time.Hour)
+	minutes := int(d%time.Hour) / int(time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one character per count, scaled to the largest count.
+// Zero is always the lowest tick.
+func sparkline(counts []int) string {
+	peak := 0
+	for _, c := range counts {
+		peak = max(peak, c)
+	}
+
+	var b strings.Builder
+	for _, c := range counts {
+		tick := 0
+		if peak > 0 && c > 0 {
+			tick = 1 + c*(len(sparkTicks)-2)/peak
+		}
+		b.WriteRune(sparkTicks[min(tick, len(sparkTicks)-1)])
+	}
+	return b.String()
+}
+
+// bar draws a horizontal bar of up to width cells for count out of peak.
+func bar(count, peak, width int) string {
+	if peak == 0 || count == 0 {
+		return ""
+	}
+	return strings.Repeat("█", max(1, count*width/peak))
+}
+
+// sortedCounts orders a count map by count, largest first, then by name.
+func sortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func (s Stats) print() {
+	fmt.Println()
+	fmt.Printf("  Todos:       %d total, %s pending, %s done\n", s.Total,
+		colorize(roleColor("pending"), fmt.Sprint(s.Pending)), colorize(roleColor("done"), fmt.Sprint(s.Done)))
+	fmt.Printf("  Completion:  %.0f%%\n", s.CompletionRate*100)
+	if s.Overdue > 0 {
+		fmt.Printf("  Overdue:     %s\n", colorize(roleColor("overdue"), fmt.Sprint(s.Overdue)))
+	} else {
+		fmt.Printf("  Overdue:     0\n")
+	}
+	if s.AvgCompletion > 0 {
+		fmt.Printf("  Avg. time to complete: %s\n", formatDuration(s.AvgCompletion))
+	}
+
+	if s.Total == 0 {
+		fmt.Println()
+		return
+	}
+
+	fmt.Println("\n  By priority:")
+	for _, p := range currentPriorities().Levels {
+		fmt.Printf("    %-8s %4d  %s\n", p, s.ByPriority[string(p)], colorize(priorityColor(p), bar(s.ByPriority[string(p)], s.Total, 30)))
+	}
+
+	fmt.Println("\n  By category:")
+	peak := 0
+	for _, c := range s.ByCategory {
+		peak = max(peak, c)
+	}
+	for _, name := range sortedCounts(s.ByCategory) {
+		fmt.Printf("    %s %4d  %s\n", padWidth(name, 12), s.ByCategory[name], bar(s.ByCategory[name], peak, 30))
+	}
+
+	if len(s.Daily) > 0 {
+		counts := make([]int, len(s.Daily))
+		total := 0
+		for i, d := range s.Daily {
+			counts[i] = d.Count
+			total += d.Count
+		}
+		fmt.Printf("\n  Completed per day (last %d days, %d total):\n", len(s.Daily), total)
+		fmt.Printf("    %s\n", sparkline(counts))
+		fmt.Printf("    %s … %s\n", s.Daily[0].Start, s.Daily[len(s.Daily)-1].Start)
+	}
+
+	if len(s.Weekly) > 0 {
+		fmt.Println("\n  Completed per week:")
+		peak := 0
+		for _, w := range s.Weekly {
+			peak = max(peak, w.Count)
+		}
+		for _, w := range s.Weekly {
+			fmt.Printf("    %s %4d  %s\n", w.Start, w.Count, bar(w.Count, peak, 30))
+		}
+	}
+	fmt.Println()
+}
+
+// padWidth pads or truncates s to exactly width display cells.
+func padWidth(s string, width int) string {
+	s = truncateWidth(s, width)
+	return s + strings.Repeat(" ", width-displayWidth(s))
+}
+
+func cmdStats(format string, days, weeks int) error {
+	if days < 0 || weeks < 0 {
+		return fmt.Errorf("--days and --weeks can not be negative")
+	}
+
+	todos, err := getTodos(todoFilter{ShowAll: true, Snoozed: true, Project: activeProjectID()})
+	if err != nil {
+		return err
+	}
+
+	s := computeStats(todos, clock(), days, weeks)
+
+	switch format {
+	case "text", "":
+		s.print()
+	case "json":
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	default:
+		return fmt.Errorf("invalid format %q. Use text or json", format)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeStats(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 10, 21, 15, 0, 0, 0, time.Local)
+	at := func(days int, hour int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 10, 21-days, hour, 0, 0, 0, time.Local), Valid: true}
+	}
+	created := func(days int) time.Time {
+		return time.Date(2026, 10, 21-days, 9, 0, 0, 0, time.Local)
+	}
+	due := func(days int) sql.NullTime {
+		return sql.NullTime{Time: time.Date(2026, 10, 21+days, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	todos := []Todo{
+		{ID: 1, Priority: PriorityHigh, Category: "work", CreatedAt: created(3), DueDate: due(-1)},
+		{ID: 2, Priority: PriorityMedium, Category: "work", CreatedAt: created(3), DueDate: due(0)},
+		{ID: 3, Priority: PriorityLow, CreatedAt: created(2)},
+		{ID: 4, Priority: PriorityHigh, Category: "home", Done: true, CreatedAt: created(2), CompletedAt: at(0, 9)},
+		{ID: 5, Priority: PriorityMedium, Category: "work", Done: true, CreatedAt: created(10), CompletedAt: at(8, 21)},
+		{ID: 6, Priority: PriorityMedium, Done: true, CreatedAt: created(1)},
+	}
+
+	s := computeStats(todos, now, 7, 3)
+
+	if s.Total != 6 || s.Pending != 3 || s.Done != 3 {
+		t.Errorf("totals = %d/%d/%d, want 6/3/3", s.Total, s.Pending, s.Done)
+	}
+	if s.Overdue != 1 {
+		t.Errorf("Overdue = %d, want 1 (due today is not overdue)", s.Overdue)
+	}
+	if s.CompletionRate != 0.5 {
+		t.Errorf("CompletionRate = %v, want 0.5", s.CompletionRate)
+	}
+	if s.ByPriority["high"] != 2 || s.ByPriority["medium"] != 3 || s.ByPriority["low"] != 1 {
+		t.Errorf("ByPriority = %v", s.ByPriority)
+	}
+	if s.ByCategory["work"] != 3 || s.ByCategory["home"] != 1 || s.ByCategory["(none)"] != 2 {
+		t.Errorf("ByCategory = %v", s.ByCategory)
+	}
+
+	// 2 days for #4 and 2d 12h for #5, averaged over the two timed todos
+	if want := 54 * time.Hour; s.AvgCompletion != want {
+		t.Errorf("AvgCompletion = %v, want %v", s.AvgCompletion, want)
+	}
+
+	if len(s.Daily) != 7 || s.Daily[6].Start != "2026-10-21" || s.Daily[6].Count != 1 {
+		t.Errorf("Daily = %+v, want today last with one completion", s.Daily)
+	}
+	for _, d := range s.Daily[:6] {
+		if d.Count != 0 {
+			t.Errorf("Daily = %+v, completion 8 days ago should be outside the window", s.Daily)
+		}
+	}
+
+	// Weeks start on Monday: 2026-10-19, 2026-10-12 and 2026-10-05
+	want := []periodCount{{"2026-10-05", 0}, {"2026-10-12", 1}, {"2026-10-19", 1}}
+	for i, w := range want {
+		if s.Weekly[i] != w {
+			t.Errorf("Weekly = %+v, want %+v", s.Weekly, want)
+			break
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{counts: []int{0, 0, 0}, want: "▁▁▁"},
+		{counts: []int{0, 1, 2, 4}, want: "▁▃▅█"},
+		{counts: []int{5}, want: "█"},
+		{counts: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := sparkline(tt.counts); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 45 * time.Minute, want: "45m"},
+		{d: 3*time.Hour + 12*time.Minute, want: "3h 12m"},
+		{d: 52 * time.Hour, want: "2d 4h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestCmdStats(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	setClock(t, time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local))
+	insertTestTodo(t, "One", PriorityHigh, "work", "")
+	insertTestTodo(t, "Two", PriorityLow, "", "")
+	markTodoAsDone(1)
+
+	var err error
+	out := captureOutput(func() { err = cmdStats("json", 7, 4) })
+	if err != nil {
+		t.Fatalf("cmdStats() error = %v", err)
+	}
+
+	var s Stats
+	if err := json.Unmarshal([]byte(out), &s); err != nil {
+		t.Fatalf("cmdStats() output is not JSON: %v\n%s", err, out)
+	}
+	if s.Total != 2 || s.Done != 1 || len(s.Daily) != 7 || len(s.Weekly) != 4 {
+		t.Errorf("cmdStats() JSON = %+v", s)
+	}
+	if s.Daily[6].Start != "2025-03-12" {
+		t.Errorf("cmdStats() last day = %s, want the clock's 2025-03-12", s.Daily[6].Start)
+	}
+	if s.Daily[6].Count != 1 {
+		t.Errorf("cmdStats() today's completions = %d, want 1", s.Daily[6].Count)
+	}
+
+	out = captureOutput(func() { err = cmdStats("text", 7, 4) })
+	if err != nil || !strings.Contains(out, "Completion:  50%") {
+		t.Errorf("cmdStats() text = %v\n%s", err, out)
+	}
+
+	if err := cmdStats("yaml", 7, 4); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("cmdStats() with bad format error = %v", err)
+	}
+}
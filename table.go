@@ -3,62 +3,281 @@ package main
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
-	"unicode/utf8"
 )
 
+// minFlexWidth is the narrowest a flexible column is squeezed to.
+const minFlexWidth = 8
+
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+)
+
+// TableStyle describes how a table is framed. Lines are given as left,
+// middle, right and fill pieces; an empty fill leaves the line out.
+type TableStyle struct {
+	Top       [4]string
+	HeaderSep [4]string
+	Bottom    [4]string
+
+	// Cell borders and the spaces either side of a cell's content
+	Left, Mid, Right string
+	Pad              int
+
+	// Markdown writes the header separator with alignment colons and
+	// never wraps cells, since a row must stay on one line.
+	Markdown bool
+}
+
+var tableStyles = map[string]TableStyle{
+	"unicode": {
+		Top:       [4]string{"┌", "┬", "┐", "─"},
+		HeaderSep: [4]string{"├", "┼", "┤", "─"},
+		Bottom:    [4]string{"└", "┴", "┘", "─"},
+		Left:      "│", Mid: "│", Right: "│",
+		Pad: 1,
+	},
+	"ascii": {
+		Top:       [4]string{"+", "+", "+", "-"},
+		HeaderSep: [4]string{"+", "+", "+", "-"},
+		Bottom:    [4]string{"+", "+", "+", "-"},
+		Left:      "|", Mid: "|", Right: "|",
+		Pad: 1,
+	},
+	"markdown": {
+		Left: "|", Mid: "|", Right: "|",
+		Pad:      1,
+		Markdown: true,
+	},
+	"compact": {
+		HeaderSep: [4]string{"", "  ", "", "─"},
+		Mid:       "  ",
+	},
+}
+
+func tableStyleNames() []string {
+	names := make([]string, 0, len(tableStyles))
+	for name := range tableStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 type Table struct {
 	Headers []string
 	Rows    [][]string
 	Widths  []int
+	Style   TableStyle
+
+	// MaxWidth is the widest the table may be drawn, borders included.
+	// Zero means unknown, in which case columns keep their natural width.
+	MaxWidth int
+
+	// Flexible marks columns that may shrink to fit MaxWidth. Cells that
+	// no longer fit are word-wrapped if Wrap is set, truncated otherwise.
+	Flexible []bool
+	Wrap     bool
+
+	Align []Alignment
 }
 
 func NewTable(headers []string) *Table {
 	widths := make([]int, len(headers))
 	for i, h := range headers {
-		widths[i] = utf8.RuneCountInString(h)
+		widths[i] = displayWidth(h)
+	}
+
+	style, ok := tableStyles[cfg.Get("table.style")]
+	if !ok {
+		style = tableStyles["unicode"]
 	}
 
 	return &Table{
-		Headers: headers,
-		Rows:    [][]string{},
-		Widths:  widths,
+		Headers:  headers,
+		Rows:     [][]string{},
+		Widths:   widths,
+		Style:    style,
+		MaxWidth: terminalWidth(),
+		Flexible: make([]bool, len(headers)),
+		Wrap:     true,
+		Align:    make([]Alignment, len(headers)),
+	}
+}
+
+// SetFlexible marks the named columns as allowed to shrink.
+func (t *Table) SetFlexible(names ...string) {
+	for i, h := range t.Headers {
+		for _, name := range names {
+			if h == name {
+				t.Flexible[i] = true
+			}
+		}
+	}
+}
+
+// SetAlign sets the alignment of the named columns.
+func (t *Table) SetAlign(align Alignment, names ...string) {
+	for i, h := range t.Headers {
+		for _, name := range names {
+			if h == name {
+				t.Align[i] = align
+			}
+		}
 	}
 }
 
 func (t *Table) AddRow(row []string) {
 	t.Rows = append(t.Rows, row)
 	for i, cell := range row {
-		cellLen := utf8.RuneCountInString(stripAnsi(cell))
+		cellLen := displayWidth(cell)
 		if i < len(t.Widths) && cellLen > t.Widths[i] {
 			t.Widths[i] = cellLen
 		}
 	}
 }
 
-func (t *Table) drawLine(left, mid, right, fill string) string {
-	parts := make([]string, len(t.Widths))
-	for i, w := range t.Widths {
-		parts[i] = strings.Repeat(fill, w+2)
+// alignWidths gives tables with the same columns the same widths, so
+// sections printed one after another line up.
+func alignWidths(tables []*Table) {
+	for _, a := range tables {
+		for _, b := range tables {
+			for i := range a.Widths {
+				if i < len(b.Widths) && b.Widths[i] > a.Widths[i] {
+					a.Widths[i] = b.Widths[i]
+				}
+			}
+		}
+	}
+}
+
+// totalWidth is how wide a row is drawn with the given column widths.
+func (t *Table) totalWidth(widths []int) int {
+	s := t.Style
+	total := displayWidth(s.Left) + displayWidth(s.Right)
+	for i, w := range widths {
+		total += w + 2*s.Pad
+		if i > 0 {
+			total += displayWidth(s.Mid)
+		}
+	}
+	return total
+}
+
+// layout returns the column widths to draw with, shrinking the widest
+// flexible column one cell at a time until the table fits MaxWidth or
+// nothing more can give.
+func (t *Table) layout() []int {
+	widths := append([]int(nil), t.Widths...)
+	if t.MaxWidth <= 0 || t.Style.Markdown {
+		return widths
+	}
+
+	total := t.totalWidth(widths)
+	for total > t.MaxWidth {
+		widest := -1
+		for i, w := range widths {
+			if !t.Flexible[i] || w <= minFlexWidth {
+				continue
+			}
+			if widest < 0 || w > widths[widest] {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		widths[widest]--
+		total--
+	}
+
+	return widths
+}
+
+// drawLine renders a horizontal rule, or "" if the style has none there.
+func (t *Table) drawLine(widths []int, pieces [4]string) string {
+	left, mid, right, fill := pieces[0], pieces[1], pieces[2], pieces[3]
+	if fill == "" {
+		return ""
+	}
+
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strings.Repeat(fill, w+2*t.Style.Pad)
 	}
 	return left + strings.Join(parts, mid) + right
 }
 
-func (t *Table) drawRow(cells []string) string {
-	parts := make([]string, len(t.Widths))
-	for i, w := range t.Widths {
+// drawMarkdownSep renders the |---|--:| line under a markdown header.
+func (t *Table) drawMarkdownSep(widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		dashes := max(w, 3)
+		if t.Align[i] == AlignRight {
+			parts[i] = " " + strings.Repeat("-", dashes-1) + ": "
+		} else {
+			parts[i] = " " + strings.Repeat("-", dashes) + " "
+		}
+	}
+	return "|" + strings.Join(parts, "|") + "|"
+}
+
+// drawRow renders one row. A row whose cells wrap spans several lines.
+func (t *Table) drawRow(widths []int, cells []string, wrap bool) []string {
+	s := t.Style
+	columns := make([][]string, len(widths))
+	height := 1
+	for i, w := range widths {
 		cell := ""
 		if i < len(cells) {
 			cell = cells[i]
 		}
+		if s.Markdown {
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+		}
+
+		if displayWidth(cell) <= w || s.Markdown {
+			columns[i] = []string{cell}
+		} else if wrap {
+			columns[i] = wrapWidth(cell, w)
+		} else {
+			columns[i] = []string{truncateWidth(cell, w)}
+		}
 
-		padding := w - utf8.RuneCountInString(stripAnsi(cell))
-		if padding < 0 {
-			padding = 0
+		if len(columns[i]) > height {
+			height = len(columns[i])
 		}
-		parts[i] = " " + cell + strings.Repeat(" ", padding) + " "
 	}
-	return "|" + strings.Join(parts, "|") + "|"
+
+	pad := strings.Repeat(" ", s.Pad)
+	lines := make([]string, height)
+	for l := range lines {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			cell := ""
+			if l < len(columns[i]) {
+				cell = columns[i][l]
+			}
+
+			padding := strings.Repeat(" ", max(w-displayWidth(cell), 0))
+			if t.Align[i] == AlignRight {
+				parts[i] = pad + padding + cell + pad
+			} else {
+				parts[i] = pad + cell + padding + pad
+			}
+		}
+		line := s.Left + strings.Join(parts, s.Mid) + s.Right
+		if s.Right == "" {
+			line = strings.TrimRight(line, " ")
+		}
+		lines[l] = line
+	}
+	return lines
 }
 
 func (t *Table) Print() {
@@ -66,25 +285,41 @@ func (t *Table) Print() {
 		return
 	}
 
+	widths := t.layout()
+	printLine := func(line string) {
+		if line != "" {
+			fmt.Println(line)
+		}
+	}
+
 	// Top border
-	fmt.Println(t.drawLine("┌", "┬", "┐", "─"))
+	printLine(t.drawLine(widths, t.Style.Top))
 
 	// Header row
-	fmt.Println(t.drawRow(t.Headers))
+	for _, line := range t.drawRow(widths, t.Headers, false) {
+		fmt.Println(line)
+	}
 
 	// Header separator
-	fmt.Println(t.drawLine("├", "┼", "┤", "─"))
+	if t.Style.Markdown {
+		fmt.Println(t.drawMarkdownSep(widths))
+	} else {
+		printLine(t.drawLine(widths, t.Style.HeaderSep))
+	}
 
 	// Data rows
 	for _, row := range t.Rows {
-		fmt.Println(t.drawRow(row))
+		for _, line := range t.drawRow(widths, row, t.Wrap) {
+			fmt.Println(line)
+		}
 	}
 
 	// Bottom border
-	fmt.Println(t.drawLine("└", "┴", "┘", "─"))
+	printLine(t.drawLine(widths, t.Style.Bottom))
 }
 
+var ansiRe = regexp.MustCompile(`\033\[[0-9;]*m`)
+
 func stripAnsi(str string) string {
-	re := regexp.MustCompile(`\033\[[0-9;]*m`)
-	return re.ReplaceAllString(str, "")
+	return ansiRe.ReplaceAllString(str, "")
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -32,7 +33,7 @@ func TestNewTable(t *testing.T) {
 		{
 			name:        "unicode headers",
 			headers:     []string{"✓", "名前"},
-			wantWidths:  []int{1, 2},
+			wantWidths:  []int{1, 4},
 			wantHeaders: []string{"✓", "名前"},
 		},
 	}
@@ -166,3 +167,220 @@ func TestStripAnsi(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "ascii", input: "hello", want: 5},
+		{name: "check mark", input: "✓", want: 1},
+		{name: "CJK", input: "名前", want: 4},
+		{name: "fullwidth", input: "ＡＢ", want: 4},
+		{name: "hangul", input: "한국", want: 4},
+		{name: "emoji", input: "🚀 go", want: 5},
+		{name: "emoji with variation selector", input: "❤️", want: 1},
+		{name: "ZWJ sequence", input: "👨‍👩‍👧", want: 2},
+		{name: "combining accent", input: "e\u0301", want: 1},
+		{name: "ANSI codes ignored", input: "\033[31m名前\033[0m", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayWidth(tt.input); got != tt.want {
+				t.Errorf("displayWidth(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		want  string
+	}{
+		{input: "short", width: 10, want: "short"},
+		{input: "Buy groceries", width: 8, want: "Buy gro…"},
+		{input: "名前名前", width: 5, want: "名前…"},
+		{input: "\033[31mcolored text\033[0m", width: 5, want: "colo…"},
+		{input: "abc", width: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncateWidth(tt.input, tt.width); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+		}
+		if got := displayWidth(truncateWidth(tt.input, tt.width)); got > tt.width && tt.width > 0 {
+			t.Errorf("truncateWidth(%q, %d) is %d cells wide", tt.input, tt.width, got)
+		}
+	}
+}
+
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		input string
+		width int
+		want  []string
+	}{
+		{input: "fits", width: 10, want: []string{"fits"}},
+		{input: "Call the bank about the mortgage", width: 12, want: []string{"Call the", "bank about", "the mortgage"}},
+		{input: "supercalifragilistic", width: 8, want: []string{"supercal", "ifragili", "stic"}},
+		{input: "名前 名前名前", width: 4, want: []string{"名前", "名前", "名前"}},
+		{input: "名前", width: 1, want: []string{"名", "前"}},
+		{input: "a🎉b", width: 1, want: []string{"a", "🎉", "b"}},
+	}
+
+	for _, tt := range tests {
+		got := wrapWidth(tt.input, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrapWidth(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestTableLayout(t *testing.T) {
+	newTable := func(maxWidth int) *Table {
+		table := NewTable([]string{"ID", "Title", "Category", "Due"})
+		table.MaxWidth = maxWidth
+		table.SetFlexible("Title", "Category")
+		table.AddRow([]string{"1", strings.Repeat("x", 40), "household chores", "2026-10-20"})
+		return table
+	}
+
+	t.Run("unknown width keeps natural widths", func(t *testing.T) {
+		widths := newTable(0).layout()
+		if widths[1] != 40 || widths[2] != 16 {
+			t.Errorf("layout() = %v, want natural widths", widths)
+		}
+	})
+
+	t.Run("shrinks flexible columns to fit", func(t *testing.T) {
+		widths := newTable(60).layout()
+		total := 1
+		for _, w := range widths {
+			total += w + 3
+		}
+		if total != 60 {
+			t.Errorf("layout() total width = %d, want 60 (%v)", total, widths)
+		}
+		if widths[0] != 2 || widths[3] != 10 {
+			t.Errorf("layout() changed fixed columns: %v", widths)
+		}
+	})
+
+	t.Run("stops at minimum width", func(t *testing.T) {
+		widths := newTable(10).layout()
+		if widths[1] != minFlexWidth || widths[2] != minFlexWidth {
+			t.Errorf("layout() = %v, want flexible columns at %d", widths, minFlexWidth)
+		}
+	})
+}
+
+func TestDrawRow(t *testing.T) {
+	table := NewTable([]string{"ID", "Title"})
+	table.Style = tableStyles["ascii"]
+	widths := []int{2, 8}
+
+	wrapped := table.drawRow(widths, []string{"1", "Call the bank now"}, true)
+	want := []string{"| 1  | Call the |", "|    | bank now |"}
+	if strings.Join(wrapped, "\n") != strings.Join(want, "\n") {
+		t.Errorf("drawRow() wrapped = %q, want %q", wrapped, want)
+	}
+
+	truncated := table.drawRow(widths, []string{"1", "Call the bank now"}, false)
+	if len(truncated) != 1 || truncated[0] != "| 1  | Call th… |" {
+		t.Errorf("drawRow() truncated = %q", truncated)
+	}
+
+	wide := table.drawRow([]int{4}, []string{"名前"}, true)
+	if wide[0] != "| 名前 |" {
+		t.Errorf("drawRow() wide = %q, want %q", wide[0], "| 名前 |")
+	}
+
+	table.SetAlign(AlignRight, "ID")
+	right := table.drawRow([]int{3, 5}, []string{"7", "x"}, true)
+	if right[0] != "|   7 | x     |" {
+		t.Errorf("drawRow() right aligned = %q, want %q", right[0], "|   7 | x     |")
+	}
+}
+
+func TestTableStyles(t *testing.T) {
+	tests := []struct {
+		style string
+		want  []string
+	}{
+		{
+			style: "unicode",
+			want: []string{
+				"┌────┬───────┐",
+				"│ ID │ Title │",
+				"├────┼───────┤",
+				"│  1 │ Walk  │",
+				"│ 12 │ Shop  │",
+				"└────┴───────┘",
+			},
+		},
+		{
+			style: "ascii",
+			want: []string{
+				"+----+-------+",
+				"| ID | Title |",
+				"+----+-------+",
+				"|  1 | Walk  |",
+				"| 12 | Shop  |",
+				"+----+-------+",
+			},
+		},
+		{
+			style: "markdown",
+			want: []string{
+				"| ID | Title |",
+				"| --: | ----- |",
+				"|  1 | Walk  |",
+				"| 12 | Shop  |",
+			},
+		},
+		{
+			style: "compact",
+			want: []string{
+				"ID  Title",
+				"──  ─────",
+				" 1  Walk",
+				"12  Shop",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			table := NewTable([]string{"ID", "Title"})
+			table.Style = tableStyles[tt.style]
+			table.MaxWidth = 0
+			table.SetAlign(AlignRight, "ID")
+			table.AddRow([]string{"1", "Walk"})
+			table.AddRow([]string{"12", "Shop"})
+
+			got := captureOutput(func() { table.Print() })
+			want := strings.Join(tt.want, "\n") + "\n"
+			if got != want {
+				t.Errorf("Print() with %s style =\n%s\nwant\n%s", tt.style, got, want)
+			}
+		})
+	}
+}
+
+func TestAlignWidths(t *testing.T) {
+	a := NewTable([]string{"ID", "Title"})
+	a.AddRow([]string{"1", "A much longer title"})
+	b := NewTable([]string{"ID", "Title"})
+	b.AddRow([]string{"100", "Short"})
+
+	alignWidths([]*Table{a, b})
+	for _, table := range []*Table{a, b} {
+		if table.Widths[0] != 3 || table.Widths[1] != 19 {
+			t.Errorf("alignWidths() widths = %v, want [3 19]", table.Widths)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+// stdoutWidth is unknown on platforms without TIOCGWINSZ; callers fall back
+// to $COLUMNS or an unconstrained layout.
+func stdoutWidth() int {
+	return 0
+}
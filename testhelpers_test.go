@@ -2,6 +2,8 @@ package main
 
 import (
 	"database/sql"
+	"io"
+	"os"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -15,6 +17,10 @@ func setupTestDB(t *testing.T) {
 		t.Fatalf("failed to open test database: %v", err)
 	}
 
+	// Every connection to :memory: is a separate database, so keep the pool
+	// to one connection for transactions to see the same tables.
+	db.SetMaxOpenConns(1)
+
 	err = createTables()
 	if err != nil {
 		t.Fatalf("failed to create test table: %v", err)
@@ -35,3 +41,21 @@ func insertTestTodo(t *testing.T, title string, priority Priority, category, due
 	}
 	return id
 }
+
+// captureOutput returns whatever fn prints to stdout.
+func captureOutput(fn func()) string {
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+
+	fn()
+	w.Close()
+	os.Stdout = stdout
+	return <-done
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of time entries: timers run between start and stop, manual entries
+// come from log-time, and pomodoro sessions are logged whole or, when
+// interrupted, partial.
+const (
+	entryTimer           = "timer"
+	entryManual          = "manual"
+	entryPomodoro        = "pomodoro"
+	entryPartialPomodoro = "pomodoro-partial"
+)
+
+// timeEntry is a stretch of time spent on a todo. A running timer has no
+// end yet.
+type timeEntry struct {
+	ID     int
+	TodoID int
+	Start  time.Time
+	End    sql.NullTime
+	Kind   string
+}
+
+// duration is how long the entry lasted, or has run so far.
+func (e timeEntry) duration(now time.Time) time.Duration {
+	if e.End.Valid {
+		return e.End.Time.Sub(e.Start)
+	}
+	return max(now.Sub(e.Start), 0)
+}
+
+// overlap is how much of the entry falls between from and to.
+func (e timeEntry) overlap(from, to, now time.Time) time.Duration {
+	end := now
+	if e.End.Valid {
+		end = e.End.Time
+	}
+	if e.Start.After(from) {
+		from = e.Start
+	}
+	if end.Before(to) {
+		to = end
+	}
+	return max(to.Sub(from), 0)
+}
+
+// createTimeEntriesTable also adds a unique index over running timers, so
+// at most one can run at a time.
+func createTimeEntriesTable() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS time_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME,
+		kind TEXT NOT NULL DEFAULT 'timer'
+	)`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+	CREATE UNIQUE INDEX IF NOT EXISTS time_entries_running
+	ON time_entries ((ended_at IS NULL)) WHERE ended_at IS NULL`)
+	return err
+}
+
+const timeEntryColumns = `id, todo_id, started_at, ended_at, kind`
+
+func queryTimeEntries(q querier, where string, args ...any) ([]timeEntry, error) {
+	rows, err := q.Query(`SELECT `+timeEntryColumns+` FROM time_entries `+where+` ORDER BY started_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []timeEntry
+	for rows.Next() {
+		var e timeEntry
+		if err := rows.Scan(&e.ID, &e.TodoID, &e.Start, &e.End, &e.Kind); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// runningEntry returns the running timer, or nil if none is running.
+func runningEntry(q querier) (*timeEntry, error) {
+	entries, err := queryTimeEntries(q, `WHERE ended_at IS NULL`)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// startTimer starts a timer on todo id at now, first stopping the running
+// timer if there is one. It returns the stopped timer.
+func startTimer(id int, now time.Time) (*timeEntry, error) {
+	_, stopped, err := startEntry(id, now, entryTimer)
+	return stopped, err
+}
+
+// startEntry starts a running entry of kind on todo id at now, first
+// stopping the running timer if there is one. It returns the ID of the new
+// entry and the stopped timer.
+func startEntry(id int, now time.Time, kind string) (int, *timeEntry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	stopped, err := stopTimerIn(tx, now)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO time_entries (todo_id, started_at, kind) VALUES (?, ?, ?)`, id, storedTime(now), kind)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, err := result.LastInsertId()
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(entry), stopped, tx.Commit()
+}
+
+// endEntry ends running entry id at end as kind. It reports false if the
+// entry was no longer running, having been stopped by another timer or by
+// completing its todo.
+func endEntry(id int, end time.Time, kind string) (bool, error) {
+	result, err := db.Exec(`UPDATE time_entries SET ended_at = ?, kind = ? WHERE id = ? AND ended_at IS NULL`, storedTime(end), kind, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// discardEntry removes running entry id without logging its time.
+func discardEntry(id int) error {
+	_, err := db.Exec(`DELETE FROM time_entries WHERE id = ? AND ended_at IS NULL`, id)
+	return err
+}
+
+// stopTimer stops the running timer at now and returns it, or nil if no
+// timer was running.
+func stopTimer(now time.Time) (*timeEntry, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stopped, err := stopTimerIn(tx, now)
+	if err != nil {
+		return nil, err
+	}
+	return stopped, tx.Commit()
+}
+
+func stopTimerIn(tx *sql.Tx, now time.Time) (*timeEntry, error) {
+	running, err := runningEntry(tx)
+	if err != nil || running == nil {
+		return nil, err
+	}
+
+	end := storedTime(now)
+	if _, err := tx.Exec(`UPDATE time_entries SET ended_at = ? WHERE id = ?`, end, running.ID); err != nil {
+		return nil, err
+	}
+	running.End = sql.NullTime{Time: end, Valid: true}
+	return running, nil
+}
+
+// stopTimerOn stops the running timer if it runs on one of the todos
+// matching where, so completing or deleting a todo doesn't leave its time
+// growing.
+func stopTimerOn(tx *sql.Tx, now time.Time, where string, args ...any) error {
+	_, err := tx.Exec(`UPDATE time_entries SET ended_at = ?
+		WHERE ended_at IS NULL AND todo_id IN (SELECT id FROM todos `+where+`)`,
+		append([]any{storedTime(now)}, args...)...)
+	return err
+}
+
+// logTime records d spent on todo id from start, as an entry of kind.
+func logTime(id int, start time.Time, d time.Duration, kind string) error {
+	_, err := db.Exec(
+		`INSERT INTO time_entries (todo_id, started_at, ended_at, kind) VALUES (?, ?, ?, ?)`,
+		id, storedTime(start), storedTime(start.Add(d)), kind,
+	)
+	return err
+}
+
+// entriesBetween returns the entries that overlap from to to.
+func entriesBetween(from, to time.Time) ([]timeEntry, error) {
+	return queryTimeEntries(db, `WHERE started_at < ? AND (ended_at IS NULL OR ended_at > ?)`, storedTime(to), storedTime(from))
+}
+
+// timeSpent adds up all time tracked on todo id, including a running timer.
+func timeSpent(id int, now time.Time) (time.Duration, error) {
+	entries, err := queryTimeEntries(db, `WHERE todo_id = ?`, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, e := range entries {
+		total += e.duration(now)
+	}
+	return total, nil
+}
+
+// timeSpentOn totals the time tracked on each of todos in one query, for
+// lists that show it per row.
+func timeSpentOn(todos []Todo, now time.Time) (map[int]time.Duration, error) {
+	spent := map[int]time.Duration{}
+	if len(todos) == 0 {
+		return spent, nil
+	}
+
+	marks := make([]string, len(todos))
+	args := []any{storedTime(now)}
+	for i, todo := range todos {
+		marks[i] = "?"
+		args = append(args, todo.ID)
+	}
+
+	rows, err := db.Query(`
+	SELECT todo_id, SUM(MAX(strftime('%s', COALESCE(ended_at, ?)) - strftime('%s', started_at), 0))
+	FROM time_entries WHERE todo_id IN (`+strings.Join(marks, ", ")+`)
+	GROUP BY todo_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var seconds int64
+		if err := rows.Scan(&id, &seconds); err != nil {
+			return nil, err
+		}
+		spent[id] = time.Duration(seconds) * time.Second
+	}
+	return spent, rows.Err()
+}
+
+// formatSpent renders tracked time in hours and minutes, the way it is
+// billed: 45m, 1h 30m, 27h 05m.
+func formatSpent(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int(d%time.Hour) / int(time.Minute)
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", hours, minutes)
+}
+
+func cmdStart(id int) error {
+	todo, err := getTodoByID(id)
+	if err != nil {
+		return err
+	}
+	if todo.Done {
+		return fmt.Errorf("todo #%d is done. Use undone to reopen it first", id)
+	}
+
+	running, err := runningEntry(db)
+	if err != nil {
+		return err
+	}
+	if running != nil && running.TodoID == id {
+		fmt.Printf("Timer already running on #%d since %s\n", id, running.Start.Local().Format("15:04"))
+		return nil
+	}
+
+	now := clock()
+	stopped, err := startTimer(id, now)
+	if err != nil {
+		return err
+	}
+	if stopped != nil {
+		fmt.Printf("%s Stopped timer on #%d after %s\n", colorize(Blue, "■"), stopped.TodoID, formatSpent(stopped.duration(now)))
+	}
+	fmt.Printf("%s Started timer on #%d: %s\n", colorize(Green, "▶"), id, todo.Title)
+	return nil
+}
+
+func cmdStop() error {
+	now := clock()
+	stopped, err := stopTimer(now)
+	if err != nil {
+		return err
+	}
+	if stopped == nil {
+		fmt.Println("No timer running")
+		return nil
+	}
+
+	total, err := timeSpent(stopped.TodoID, now)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Stopped timer on #%d after %s (%s in total)\n", colorize(Blue, "■"), stopped.TodoID,
+		formatSpent(stopped.duration(now)), formatSpent(total))
+	return nil
+}
+
+// cmdLogTime records time spent without a timer. The entry ends now, or
+// starts at midnight on date if one is given.
+func cmdLogTime(id int, duration, date string) error {
+	d, err := parseDuration(duration)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("log a positive duration, e.g. 1h30m")
+	}
+
+	if _, err := getTodoByID(id); err != nil {
+		return err
+	}
+
+	now := clock()
+	start := now.Add(-d)
+	if date != "" {
+		day, err := parseSince(date)
+		if err != nil {
+			return err
+		}
+		start = startOfDay(day)
+	}
+
+	if err := logTime(id, start, d, entryManual); err != nil {
+		return err
+	}
+
+	total, err := timeSpent(id, now)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Logged %s on #%d (%s in total)\n", colorize(Green, "✓"), formatSpent(d), id, formatSpent(total))
+	return nil
+}
+
+// timesheetRow is the time spent on one todo, per day of the timesheet.
+type timesheetRow struct {
+	TodoID   int
+	Title    string
+	Category string
+	Days     []time.Duration
+	Total    time.Duration
+}
+
+// buildTimesheet splits the time tracked from from over days days by todo
+// and day. Todos that were deleted keep their time under "(deleted)".
+func buildTimesheet(entries []timeEntry, todos map[int]Todo, from time.Time, days int, now time.Time) []timesheetRow {
+	byTodo := map[int]*timesheetRow{}
+	for _, e := range entries {
+		row, ok := byTodo[e.TodoID]
+		if !ok {
+			row = &timesheetRow{TodoID: e.TodoID, Title: "(deleted)", Days: make([]time.Duration, days)}
+			if todo, ok := todos[e.TodoID]; ok {
+				row.Title, row.Category = todo.Title, todo.Category
+			}
+			byTodo[e.TodoID] = row
+		}
+
+		for d := 0; d < days; d++ {
+			spent := e.overlap(from.AddDate(0, 0, d), from.AddDate(0, 0, d+1), now)
+			row.Days[d] += spent
+			row.Total += spent
+		}
+	}
+
+	rows := make([]timesheetRow, 0, len(byTodo))
+	for _, row := range byTodo {
+		if row.Total > 0 {
+			rows = append(rows, *row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Category != rows[j].Category {
+			return rows[i].Category < rows[j].Category
+		}
+		return rows[i].TodoID < rows[j].TodoID
+	})
+	return rows
+}
+
+// cmdTimesheet shows the time tracked in a week, or in a single day when
+// day is set, per todo and per category. date picks the week or day;
+// empty means the current one.
+func cmdTimesheet(day bool, date string) error {
+	now := clock()
+	from := startOfDay(now)
+	if date != "" {
+		d, err := parseSince(date)
+		if err != nil {
+			return err
+		}
+		from = startOfDay(d)
+	}
+
+	days := 1
+	if !day {
+		days = 7
+		from = startOfWeek(from)
+	}
+	to := from.AddDate(0, 0, days)
+
+	entries, err := entriesBetween(from, to)
+	if err != nil {
+		return err
+	}
+	// Time spent on todos of archived projects still counts
+	all, err := queryTodos(db, `SELECT `+todoColumns+` FROM todos`)
+	if err != nil {
+		return err
+	}
+	todos := make(map[int]Todo, len(all))
+	for _, todo := range all {
+		todos[todo.ID] = todo
+	}
+
+	rows := buildTimesheet(entries, todos, from, days, now)
+
+	if day {
+		fmt.Printf("\nTimesheet for %s:\n", from.Format(cfg.Get("date.format")))
+	} else {
+		fmt.Printf("\nTimesheet for the week of %s:\n", from.Format(cfg.Get("date.format")))
+	}
+	fmt.Println("---------------------------------------")
+	if len(rows) == 0 {
+		fmt.Println("No time tracked")
+		return nil
+	}
+
+	headers := []string{"ID", "Title", "Category"}
+	var dayHeaders []string
+	if days > 1 {
+		for d := 0; d < days; d++ {
+			dayHeaders = append(dayHeaders, from.AddDate(0, 0, d).Format("Mon 02"))
+		}
+	}
+	headers = append(append(headers, dayHeaders...), "Total")
+
+	table := NewTable(headers)
+	table.SetFlexible("Title", "Category")
+	table.SetAlign(AlignRight, append(dayHeaders, "ID", "Total")...)
+
+	byCategory := map[string]time.Duration{}
+	dayTotals := make([]time.Duration, days)
+	var total time.Duration
+	for _, row := range rows {
+		cells := []string{fmt.Sprintf("%d", row.TodoID), row.Title, row.Category}
+		for d, spent := range row.Days {
+			dayTotals[d] += spent
+			if days > 1 {
+				cells = append(cells, timesheetCell(spent))
+			}
+		}
+		table.AddRow(append(cells, formatSpent(row.Total)))
+		byCategory[row.Category] += row.Total
+		total += row.Total
+	}
+
+	totals := []string{"", colorize(Bold, "Total"), ""}
+	if days > 1 {
+		for _, spent := range dayTotals {
+			totals = append(totals, timesheetCell(spent))
+		}
+	}
+	table.AddRow(append(totals, colorize(Bold, formatSpent(total))))
+	table.Print()
+
+	fmt.Println("\nBy category:")
+	categories := make([]string, 0, len(byCategory))
+	width := len("(none)")
+	for category := range byCategory {
+		categories = append(categories, category)
+		width = max(width, displayWidth(category))
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		name := category
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("  %s %9s\n", padWidth(name, width), formatSpent(byCategory[category]))
+	}
+
+	if running, err := runningEntry(db); err == nil && running != nil {
+		fmt.Printf("\nTimer running on #%d since %s\n", running.TodoID, running.Start.Local().Format("15:04"))
+	}
+	fmt.Println()
+	return nil
+}
+
+// timesheetCell leaves days without tracked time blank.
+func timesheetCell(d time.Duration) string {
+	if d < time.Minute {
+		return ""
+	}
+	return formatSpent(d)
+}
+
+// printTimeSpent adds the tracked time to show, noting a running timer.
+func printTimeSpent(id int) error {
+	now := clock()
+	spent, err := timeSpent(id, now)
+	if err != nil || spent == 0 {
+		return err
+	}
+
+	running, err := runningEntry(db)
+	if err != nil {
+		return err
+	}
+	if running != nil && running.TodoID == id {
+		fmt.Printf("  Spent:     %s (timer running for %s)\n", formatSpent(spent), formatSpent(running.duration(now)))
+		return nil
+	}
+	fmt.Printf("  Spent:     %s\n", formatSpent(spent))
+	return nil
+}
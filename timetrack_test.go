@@ -0,0 +1,258 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSpent(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "0m"},
+		{d: 45 * time.Minute, want: "45m"},
+		{d: 90 * time.Minute, want: "1h 30m"},
+		{d: 27*time.Hour + 5*time.Minute + 20*time.Second, want: "27h 05m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatSpent(tt.d); got != tt.want {
+			t.Errorf("formatSpent(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTimesheet(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	now := monday.AddDate(0, 0, 2).Add(10 * time.Hour)
+	ended := func(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: true} }
+
+	entries := []timeEntry{
+		// Past midnight, so split over Monday and Tuesday
+		{TodoID: 1, Start: monday.Add(23 * time.Hour), End: ended(monday.Add(25 * time.Hour))},
+		{TodoID: 2, Start: monday.Add(9 * time.Hour), End: ended(monday.Add(9*time.Hour + 30*time.Minute))},
+		// Still running on Wednesday
+		{TodoID: 1, Start: now.Add(-time.Hour)},
+		{TodoID: 9, Start: monday.Add(-time.Hour), End: ended(monday.Add(15 * time.Minute))},
+	}
+	todos := map[int]Todo{
+		1: {ID: 1, Title: "Report", Category: "work"},
+		2: {ID: 2, Title: "Groceries", Category: "home"},
+	}
+
+	rows := buildTimesheet(entries, todos, monday, 7, now)
+	if len(rows) != 3 {
+		t.Fatalf("buildTimesheet() = %d rows, want 3", len(rows))
+	}
+
+	// Sorted by category, deleted todos without one first
+	if rows[0].TodoID != 9 || rows[0].Title != "(deleted)" || rows[0].Total != 15*time.Minute {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[1].TodoID != 2 || rows[1].Days[0] != 30*time.Minute {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+	report := rows[2]
+	if report.Days[0] != time.Hour || report.Days[1] != time.Hour || report.Days[2] != time.Hour || report.Total != 3*time.Hour {
+		t.Errorf("rows[2] days = %v, total %v", report.Days[:3], report.Total)
+	}
+}
+
+func TestTimers(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	setClock(t, start)
+	insertTestTodo(t, "Report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+
+	out := captureOutput(func() { cmdStop() })
+	if !strings.Contains(out, "No timer running") {
+		t.Errorf("cmdStop() without a timer = %q", out)
+	}
+
+	captureOutput(func() {
+		if err := cmdStart(1); err != nil {
+			t.Fatalf("cmdStart() error = %v", err)
+		}
+	})
+	out = captureOutput(func() { cmdStart(1) })
+	if !strings.Contains(out, "already running on #1") {
+		t.Errorf("second cmdStart(1) = %q", out)
+	}
+
+	// Starting another timer stops the first
+	setClock(t, start.Add(90*time.Minute))
+	out = stripAnsi(captureOutput(func() { cmdStart(2) }))
+	if !strings.Contains(out, "Stopped timer on #1 after 1h 30m") || !strings.Contains(out, "Started timer on #2") {
+		t.Errorf("cmdStart(2) output = %q", out)
+	}
+
+	setClock(t, start.Add(2*time.Hour))
+	out = stripAnsi(captureOutput(func() { cmdShow(2) }))
+	if !strings.Contains(out, "Spent:     30m (timer running for 30m)") {
+		t.Errorf("cmdShow() with a running timer:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdStop() }))
+	if !strings.Contains(out, "Stopped timer on #2 after 30m (30m in total)") {
+		t.Errorf("cmdStop() output = %q", out)
+	}
+	if running, _ := runningEntry(db); running != nil {
+		t.Errorf("runningEntry() after stop = %+v", running)
+	}
+
+	// At most one timer runs, even if something bypasses startTimer
+	db.Exec(`INSERT INTO time_entries (todo_id, started_at) VALUES (1, ?)`, storedTime(clock()))
+	if _, err := db.Exec(`INSERT INTO time_entries (todo_id, started_at) VALUES (2, ?)`, storedTime(clock())); err == nil {
+		t.Errorf("a second running timer should be rejected")
+	}
+
+	captureOutput(func() { cmdDone(2) })
+	if err := cmdStart(2); err == nil {
+		t.Errorf("cmdStart() on a done todo should fail")
+	}
+}
+
+func TestTimerStopsWithTodo(t *testing.T) {
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "done", run: func() error { return markTodoAsDone(1) }},
+		{name: "delete", run: func() error { return deleteTodo(1) }},
+		{name: "bulk done", run: func() error {
+			return cmdBulkStatus(todoSelection{IDs: []int{1, 2}}, true, bulkOptions{Force: true})
+		}},
+		{name: "bulk delete", run: func() error {
+			return cmdBulkDelete(todoSelection{IDs: []int{1, 2}}, bulkOptions{Force: true})
+		}},
+		{name: "clear", run: func() error {
+			if err := markTodoAsDone(2); err != nil {
+				return err
+			}
+			db.Exec(`UPDATE todos SET done = 1 WHERE id = 1`)
+			return cmdClear(false, "", true)
+		}},
+	}
+
+	for _, tt := range tests {
+		setupTestDB(t)
+		setClock(t, start)
+		insertTestTodo(t, "Report", PriorityHigh, "work", "")
+		insertTestTodo(t, "Review", PriorityMedium, "work", "")
+		startTimer(1, start)
+
+		setClock(t, start.Add(time.Hour))
+		var err error
+		captureOutput(func() { err = tt.run() })
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+
+		setClock(t, start.Add(5*time.Hour))
+		if running, _ := runningEntry(db); running != nil {
+			t.Errorf("%s: timer still running on #%d", tt.name, running.TodoID)
+		}
+		if spent, _ := timeSpent(1, clock()); spent != time.Hour {
+			t.Errorf("%s: time spent = %v, want the hour before the timer stopped", tt.name, spent)
+		}
+		teardownTestDB()
+	}
+}
+
+func TestTimeSpentOn(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	setClock(t, now)
+	insertTestTodo(t, "Report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Review", PriorityMedium, "work", "")
+	insertTestTodo(t, "Untracked", PriorityLow, "", "")
+	logTime(1, now.Add(-5*time.Hour), 90*time.Minute, entryManual)
+	logTime(1, now.Add(-3*time.Hour), 25*time.Minute, entryPomodoro)
+	startTimer(1, now.Add(-20*time.Minute))
+	logTime(2, now.Add(-2*time.Hour), 45*time.Minute, entryManual)
+
+	todos, _ := getTodos(todoFilter{})
+	spent, err := timeSpentOn(todos, now)
+	if err != nil {
+		t.Fatalf("timeSpentOn() error = %v", err)
+	}
+	for _, todo := range todos {
+		want, _ := timeSpent(todo.ID, now)
+		if spent[todo.ID] != want {
+			t.Errorf("timeSpentOn() for #%d = %v, want %v", todo.ID, spent[todo.ID], want)
+		}
+	}
+	if spent[1] != 2*time.Hour+15*time.Minute {
+		t.Errorf("timeSpentOn() for #1 = %v, want 2h15m", spent[1])
+	}
+}
+
+func TestCmdLogTime(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	setClock(t, time.Date(2026, 10, 21, 15, 0, 0, 0, time.Local))
+	insertTestTodo(t, "Report", PriorityHigh, "work", "")
+	insertTestTodo(t, "Groceries", PriorityLow, "home", "")
+
+	tests := []struct {
+		id       int
+		duration string
+		date     string
+		want     string
+		wantErr  string
+	}{
+		{id: 1, duration: "1h30m", want: "Logged 1h 30m on #1 (1h 30m in total)"},
+		{id: 1, duration: "45m", date: "2026-10-19", want: "Logged 45m on #1 (2h 15m in total)"},
+		{id: 2, duration: "20m", want: "Logged 20m on #2 (20m in total)"},
+		{id: 1, duration: "0m", wantErr: "positive duration"},
+		{id: 1, duration: "soon", wantErr: "invalid duration"},
+		{id: 99, duration: "1h", wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		var err error
+		out := stripAnsi(captureOutput(func() { err = cmdLogTime(tt.id, tt.duration, tt.date) }))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("cmdLogTime(%d, %q) error = %v, want %q", tt.id, tt.duration, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(out, tt.want) {
+			t.Errorf("cmdLogTime(%d, %q) = %q, %v, want %q", tt.id, tt.duration, out, err, tt.want)
+		}
+	}
+
+	out := stripAnsi(captureOutput(func() { cmdTimesheet(false, "") }))
+	for _, want := range []string{"Mon 19", "Wed 21", "Report", "1h 30m", "2h 15m", "2h 35m", "home", "20m"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdTimesheet() missing %q:\n%s", want, out)
+		}
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdTimesheet(true, "2026-10-19") }))
+	if !strings.Contains(out, "45m") || strings.Contains(out, "Groceries") {
+		t.Errorf("cmdTimesheet(day) for Monday:\n%s", out)
+	}
+
+	out = captureOutput(func() { cmdTimesheet(false, "2026-10-01") })
+	if !strings.Contains(out, "No time tracked") {
+		t.Errorf("cmdTimesheet() for an empty week:\n%s", out)
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdList(listOptions{Columns: "id,title,spent"}) }))
+	if !strings.Contains(out, "Spent") || !strings.Contains(out, "2h 15m") {
+		t.Errorf("list with the spent column:\n%s", out)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// urgencyWeights are the coefficients of the urgency score. Each term of
+// the score is a factor between 0 and 1 times its coefficient, except for
+// categories, whose coefficient is added as is.
+type urgencyWeights struct {
+	Priority float64
+	Due      float64
+	Age      float64
+	Category map[string]float64
+}
+
+// urgencyAgeLimit is the age at which a todo's age counts in full.
+const urgencyAgeLimit = 365 * 24 * time.Hour
+
+// urgencyTerm is one part of an urgency score, kept apart so next can
+// explain where the score comes from.
+type urgencyTerm struct {
+	Name        string
+	Detail      string
+	Factor      float64
+	Coefficient float64
+}
+
+func (t urgencyTerm) Score() float64 {
+	return t.Factor * t.Coefficient
+}
+
+// parseCategoryWeights reads per-category coefficients such as
+// "work:2,someday:-3".
+func parseCategoryWeights(s string) (map[string]float64, error) {
+	weights := map[string]float64{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		category, n, ok := strings.Cut(field, ":")
+		weight, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if !ok || err != nil || strings.TrimSpace(category) == "" {
+			return nil, fmt.Errorf("invalid category weight %q. Use category:number, e.g. work:2", field)
+		}
+		weights[strings.TrimSpace(category)] = weight
+	}
+	return weights, nil
+}
+
+func validateCategoryWeights(v string) error {
+	_, err := parseCategoryWeights(v)
+	return err
+}
+
+func validateFloat(v string) error {
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return fmt.Errorf("invalid number %q", v)
+	}
+	return nil
+}
+
+// currentUrgencyWeights reads the coefficients from the settings. Invalid
+// settings are rejected when loaded, so errors here leave a term at zero.
+func currentUrgencyWeights() urgencyWeights {
+	number := func(name string) float64 {
+		f, _ := strconv.ParseFloat(cfg.Get(name), 64)
+		return f
+	}
+
+	category, err := parseCategoryWeights(cfg.Get("urgency.category"))
+	if err != nil {
+		category = map[string]float64{}
+	}
+
+	return urgencyWeights{
+		Priority: number("urgency.priority"),
+		Due:      number("urgency.due"),
+		Age:      number("urgency.age"),
+		Category: category,
+	}
+}
+
+// dueFactor rises from 0.2 for todos due two weeks or more from today to 1
+// for todos a week or more overdue. Todos without a due date get 0.
+func dueFactor(days int) float64 {
+	switch {
+	case days <= -7:
+		return 1
+	case days >= 14:
+		return 0.2
+	}
+	return 0.2 + float64(14-days)*0.8/21
+}
+
+// terms breaks down the urgency of a pending todo.
+func (w urgencyWeights) terms(todo Todo, now time.Time) []urgencyTerm {
+	scale := currentPriorities()
+	terms := []urgencyTerm{{
+		Name:        "priority",
+		Detail:      string(todo.Priority),
+		Factor:      float64(scale.rank(todo.Priority)) / float64(len(scale.Levels)),
+		Coefficient: w.Priority,
+	}}
+
+	if todo.DueDate.Valid {
+		_, days := classifyDue(todo.DueDate, startOfDay(now))
+		detail := fmt.Sprintf("in %d day(s)", days)
+		switch {
+		case days < 0:
+			detail = fmt.Sprintf("%d day(s) overdue", -days)
+		case days == 0:
+			detail = "today"
+		}
+		terms = append(terms, urgencyTerm{Name: "due", Detail: detail, Factor: dueFactor(days), Coefficient: w.Due})
+	}
+
+	age := now.Sub(todo.CreatedAt)
+	terms = append(terms, urgencyTerm{
+		Name:        "age",
+		Detail:      formatAge(age),
+		Factor:      min(max(float64(age)/float64(urgencyAgeLimit), 0), 1),
+		Coefficient: w.Age,
+	})
+
+	if weight, ok := w.Category[todo.Category]; ok && todo.Category != "" {
+		terms = append(terms, urgencyTerm{Name: "category", Detail: todo.Category, Factor: 1, Coefficient: weight})
+	}
+	return terms
+}
+
+// score sums the terms of a todo's urgency. Done todos score 0.
+func (w urgencyWeights) score(todo Todo, now time.Time) float64 {
+	if todo.Done {
+		return 0
+	}
+
+	total := 0.0
+	for _, term := range w.terms(todo, now) {
+		total += term.Score()
+	}
+	return total
+}
+
+// urgencyScores scores todos by ID with the current settings.
+func urgencyScores(todos []Todo) map[int]float64 {
+	w, now := currentUrgencyWeights(), clock()
+	scores := make(map[int]float64, len(todos))
+	for _, todo := range todos {
+		scores[todo.ID] = w.score(todo, now)
+	}
+	return scores
+}
+
+func cmdNext(category string) error {
+	todos, err := getTodos(todoFilter{Category: category, Project: activeProjectID()})
+	if err != nil {
+		return err
+	}
+	if len(todos) == 0 {
+		fmt.Println("Nothing to do")
+		return nil
+	}
+
+	scores := urgencyScores(todos)
+	sort.SliceStable(todos, func(i, j int) bool { return scores[todos[i].ID] > scores[todos[j].ID] })
+	todo := todos[0]
+
+	fmt.Println()
+	fmt.Printf("Next: %s %s\n", colorize(Bold, fmt.Sprintf("#%d", todo.ID)), todo.Title)
+
+	details := []string{"priority " + colorize(priorityColor(todo.Priority), string(todo.Priority))}
+	if todo.DueDate.Valid {
+		details = append(details, "due "+formatDueDate(todo.DueDate))
+	}
+	if todo.Category != "" {
+		details = append(details, "category "+todo.Category)
+	}
+	fmt.Printf("  %s\n", strings.Join(details, " · "))
+
+	fmt.Println()
+	fmt.Printf("Urgency %.1f\n", scores[todo.ID])
+	terms := currentUrgencyWeights().terms(todo, clock())
+	detailWidth := 0
+	for _, term := range terms {
+		detailWidth = max(detailWidth, displayWidth(term.Detail))
+	}
+	for _, term := range terms {
+		fmt.Printf("  %-9s %s  %4.2f × %-5.1f = %5.1f\n", term.Name, padWidth(term.Detail, detailWidth), term.Factor, term.Coefficient, term.Score())
+	}
+
+	if len(todos) > 1 {
+		fmt.Printf("\n%d more todo(s) waiting. Use list --sort urgency to see them all\n", len(todos)-1)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDueFactor(t *testing.T) {
+	tests := []struct {
+		days int
+		want float64
+	}{
+		{days: -30, want: 1},
+		{days: -7, want: 1},
+		{days: 0, want: 0.2 + 14*0.8/21},
+		{days: 13, want: 0.2 + 0.8/21},
+		{days: 14, want: 0.2},
+		{days: 100, want: 0.2},
+	}
+
+	for _, tt := range tests {
+		if got := dueFactor(tt.days); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("dueFactor(%d) = %v, want %v", tt.days, got, tt.want)
+		}
+	}
+
+	// Closer due dates are always more urgent
+	for days := -7; days < 14; days++ {
+		if dueFactor(days) <= dueFactor(days+1) {
+			t.Errorf("dueFactor(%d) = %v is not above dueFactor(%d) = %v", days, dueFactor(days), days+1, dueFactor(days+1))
+		}
+	}
+}
+
+func TestParseCategoryWeights(t *testing.T) {
+	got, err := parseCategoryWeights("work:2, someday:-3.5")
+	if err != nil || got["work"] != 2 || got["someday"] != -3.5 {
+		t.Errorf("parseCategoryWeights() = %v, %v", got, err)
+	}
+
+	for _, bad := range []string{"work", "work:high", ":2"} {
+		if _, err := parseCategoryWeights(bad); err == nil {
+			t.Errorf("parseCategoryWeights(%q) should fail", bad)
+		}
+	}
+}
+
+func TestUrgencyScore(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	w := urgencyWeights{Priority: 6, Due: 12, Age: 2, Category: map[string]float64{"someday": -3}}
+	due := func(date string) sql.NullTime {
+		d, _ := time.Parse("2006-01-02", date)
+		return sql.NullTime{Time: d, Valid: true}
+	}
+
+	tests := []struct {
+		name string
+		todo Todo
+		want float64
+	}{
+		{name: "high priority, new", todo: Todo{Priority: PriorityHigh, CreatedAt: now}, want: 6},
+		{name: "low priority, half a year old", todo: Todo{Priority: PriorityLow, CreatedAt: now.Add(-urgencyAgeLimit / 2)}, want: 2 + 1},
+		{name: "overdue a week", todo: Todo{Priority: PriorityMedium, CreatedAt: now, DueDate: due("2026-10-12")}, want: 4 + 12},
+		{name: "due far off", todo: Todo{Priority: PriorityMedium, CreatedAt: now, DueDate: due("2027-01-01")}, want: 4 + 12*0.2},
+		{name: "weighted category", todo: Todo{Priority: PriorityLow, CreatedAt: now, Category: "someday"}, want: 2 - 3},
+		{name: "done", todo: Todo{Priority: PriorityHigh, CreatedAt: now, Done: true}, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := w.score(tt.todo, now); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: score() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortTodosByUrgency(t *testing.T) {
+	setClock(t, time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local))
+	now := clock()
+	overdue := sql.NullTime{Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	todos := []Todo{
+		{ID: 1, Priority: PriorityLow, CreatedAt: now},
+		{ID: 2, Priority: PriorityHigh, CreatedAt: now},
+		{ID: 3, Priority: PriorityLow, CreatedAt: now, DueDate: overdue},
+		{ID: 4, Priority: PriorityLow, CreatedAt: now},
+	}
+	if err := sortTodos(todos, "urgency"); err != nil {
+		t.Fatalf("sortTodos() error = %v", err)
+	}
+
+	var ids []int
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	if want := []int{3, 2, 1, 4}; !slices.Equal(ids, want) {
+		t.Errorf("sortTodos(urgency) = %v, want %v", ids, want)
+	}
+}
+
+func TestCmdNext(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	t.Setenv("TODO_URGENCY_CATEGORY", "work:5")
+
+	out := captureOutput(func() { cmdNext("") })
+	if !strings.Contains(out, "Nothing to do") {
+		t.Errorf("cmdNext() on an empty list = %q", out)
+	}
+
+	insertTestTodo(t, "Water plants", PriorityHigh, "home", "")
+	insertTestTodo(t, "Write report", PriorityMedium, "work", "")
+	insertTestTodo(t, "Snoozed", PriorityHigh, "work", "")
+	snoozeTodo(3, time.Now().Add(time.Hour))
+
+	var err error
+	out = stripAnsi(captureOutput(func() { err = cmdNext("") }))
+	if err != nil {
+		t.Fatalf("cmdNext() error = %v", err)
+	}
+	for _, want := range []string{"Next: #2 Write report", "Urgency 9.0", "priority  medium", "category  work", "5.0", "1 more todo(s) waiting"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("cmdNext() missing %q:\n%s", want, out)
+		}
+	}
+
+	out = stripAnsi(captureOutput(func() { cmdNext("home") }))
+	if !strings.Contains(out, "Next: #1 Water plants") {
+		t.Errorf("cmdNext(home) output:\n%s", out)
+	}
+}
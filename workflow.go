@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// workflow is the ordered list of states a todo moves through. The first
+// state is where new and reopened todos sit and the last one means done,
+// so done and undone keep working alongside move.
+type workflow struct {
+	States []string
+	WIP    map[string]int
+}
+
+var stateNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// parseStates reads a comma-separated list of at least two distinct states.
+func parseStates(s string) ([]string, error) {
+	var states []string
+	seen := map[string]bool{}
+	for _, field := range strings.Split(s, ",") {
+		state := strings.ToLower(strings.TrimSpace(field))
+		if state == "" {
+			continue
+		}
+		if !stateNameRe.MatchString(state) {
+			return nil, fmt.Errorf("invalid state %q. Use lowercase letters, digits, - and _", state)
+		}
+		if seen[state] {
+			return nil, fmt.Errorf("state %q is listed twice", state)
+		}
+		seen[state] = true
+		states = append(states, state)
+	}
+
+	if len(states) < 2 {
+		return nil, fmt.Errorf("a workflow needs at least two states, e.g. todo,done")
+	}
+	return states, nil
+}
+
+// parseWIP reads per-state limits such as "in-progress:3,review:2".
+func parseWIP(s string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		state, n, ok := strings.Cut(field, ":")
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if !ok || err != nil || limit < 1 {
+			return nil, fmt.Errorf("invalid WIP limit %q. Use state:number, e.g. in-progress:3", field)
+		}
+		limits[strings.ToLower(strings.TrimSpace(state))] = limit
+	}
+	return limits, nil
+}
+
+func validateStates(v string) error {
+	_, err := parseStates(v)
+	return err
+}
+
+func validateWIP(v string) error {
+	_, err := parseWIP(v)
+	return err
+}
+
+// currentWorkflow reads the workflow from the settings. Invalid settings
+// are rejected when loaded, so errors here fall back to the defaults.
+func currentWorkflow() workflow {
+	states, err := parseStates(cfg.Get("workflow.states"))
+	if err != nil {
+		k, _ := lookupConfigKey("workflow.states")
+		states, _ = parseStates(k.Default)
+	}
+
+	wip, err := parseWIP(cfg.Get("workflow.wip"))
+	if err != nil {
+		wip = map[string]int{}
+	}
+
+	return workflow{States: states, WIP: wip}
+}
+
+func (w workflow) initial() string {
+	return w.States[0]
+}
+
+func (w workflow) terminal() string {
+	return w.States[len(w.States)-1]
+}
+
+func (w workflow) has(state string) bool {
+	for _, s := range w.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// stateOf returns where a todo sits in the workflow. Done todos are always
+// in the terminal state; todos that were never moved, or whose state is no
+// longer part of the workflow, are in the initial one.
+func (w workflow) stateOf(todo Todo) string {
+	switch {
+	case todo.Done:
+		return w.terminal()
+	case todo.State != "" && todo.State != w.terminal() && w.has(todo.State):
+		return todo.State
+	}
+	return w.initial()
+}
+
+// overLimit lists warnings for every state holding more todos than its WIP
+// limit allows.
+func (w workflow) overLimit(counts map[string]int) []string {
+	var warnings []string
+	for _, state := range w.States {
+		if limit, ok := w.WIP[state]; ok && counts[state] > limit {
+			warnings = append(warnings, fmt.Sprintf("%s has %d todos, over its WIP limit of %d", state, counts[state], limit))
+		}
+	}
+	return warnings
+}
+
+// stateCounts counts the pending todos in each state.
+func (w workflow) stateCounts() (map[string]int, error) {
+	todos, err := getTodos(todoFilter{Project: activeProjectID()})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, todo := range todos {
+		counts[w.stateOf(todo)]++
+	}
+	return counts, nil
+}
+
+func cmdMove(id int, state string) error {
+	w := currentWorkflow()
+	state = strings.ToLower(state)
+	if !w.has(state) {
+		return fmt.Errorf("unknown state %q. Use %s", state, strings.Join(w.States, ", "))
+	}
+
+	if err := moveTodo(id, state); err != nil {
+		return err
+	}
+	fmt.Printf("%s Moved todo #%d to %s\n", colorize(Green, "✓"), id, state)
+
+	counts, err := w.stateCounts()
+	if err != nil {
+		return err
+	}
+	if limit, ok := w.WIP[state]; ok && counts[state] > limit {
+		fmt.Printf("%s %s has %d todos, over its WIP limit of %d\n", colorize(roleColor("overdue"), "Warning:"), state, counts[state], limit)
+	}
+	return nil
+}
+
+// boardGap separates the columns of the board.
+const boardGap = "  "
+
+// renderBoard lays out todos as one column per state, sized to share
+// width. Done todos completed before doneSince are left off the board.
+func renderBoard(w workflow, todos []Todo, width int, doneSince time.Time) []string {
+	columns := make(map[string][]Todo, len(w.States))
+	for _, todo := range todos {
+		if todo.Done && !doneSince.IsZero() && (!todo.CompletedAt.Valid || todo.CompletedAt.Time.Before(doneSince)) {
+			continue
+		}
+		state := w.stateOf(todo)
+		columns[state] = append(columns[state], todo)
+	}
+
+	n := len(w.States)
+	colWidth := minFlexWidth * 2
+	if width > 0 {
+		colWidth = max((width-len(boardGap)*(n-1))/n, minFlexWidth)
+	}
+
+	cells := make([][]string, n)
+	height := 0
+	for i, state := range w.States {
+		header := fmt.Sprintf("%s (%d)", strings.ToUpper(state), len(columns[state]))
+		role := Bold
+		if limit, ok := w.WIP[state]; ok {
+			header = fmt.Sprintf("%s (%d/%d)", strings.ToUpper(state), len(columns[state]), limit)
+			if len(columns[state]) > limit {
+				role = roleColor("overdue")
+			}
+		}
+
+		cells[i] = []string{
+			colorize(role, truncateWidth(header, colWidth)),
+			strings.Repeat("─", colWidth),
+		}
+		for _, todo := range columns[state] {
+			id := fmt.Sprintf("#%d ", todo.ID)
+			lines := wrapWidth(todo.Title, max(colWidth-len(id), 1))
+			for j, line := range lines {
+				prefix := strings.Repeat(" ", len(id))
+				if j == 0 {
+					prefix = colorize(priorityColor(todo.Priority), id)
+				}
+				cells[i] = append(cells[i], prefix+line)
+			}
+		}
+		height = max(height, len(cells[i]))
+	}
+
+	lines := make([]string, height)
+	for l := range lines {
+		parts := make([]string, n)
+		for i := range cells {
+			cell := ""
+			if l < len(cells[i]) {
+				cell = cells[i][l]
+			}
+			parts[i] = cell + strings.Repeat(" ", max(colWidth-displayWidth(cell), 0))
+		}
+		lines[l] = strings.TrimRight(strings.Join(parts, boardGap), " ")
+	}
+	return lines
+}
+
+func cmdBoard(category string, allDone bool) error {
+	w := currentWorkflow()
+	todos, err := getTodos(todoFilter{ShowAll: true, Category: category, Project: activeProjectID()})
+	if err != nil {
+		return err
+	}
+
+	// Only recently finished work is interesting on a board
+	doneSince := startOfDay(clock()).AddDate(0, 0, -7)
+	if allDone {
+		doneSince = time.Time{}
+	}
+
+	fmt.Println()
+	for _, line := range renderBoard(w, todos, terminalWidth(), doneSince) {
+		fmt.Println(line)
+	}
+
+	counts := map[string]int{}
+	for _, todo := range todos {
+		if !todo.Done {
+			counts[w.stateOf(todo)]++
+		}
+	}
+	if warnings := w.overLimit(counts); len(warnings) > 0 {
+		fmt.Println()
+		for _, warning := range warnings {
+			fmt.Printf("%s %s\n", colorize(roleColor("overdue"), "Warning:"), warning)
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStates(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{input: "backlog,in-progress,review,done", want: []string{"backlog", "in-progress", "review", "done"}},
+		{input: " Todo , Doing , Done ", want: []string{"todo", "doing", "done"}},
+		{input: "done", wantErr: "at least two"},
+		{input: "todo,todo,done", wantErr: "listed twice"},
+		{input: "todo,in progress,done", wantErr: "invalid state"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStates(tt.input)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseStates(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("parseStates(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseWIP(t *testing.T) {
+	got, err := parseWIP("in-progress:3, review:2")
+	if err != nil || got["in-progress"] != 3 || got["review"] != 2 {
+		t.Errorf("parseWIP() = %v, %v", got, err)
+	}
+
+	for _, bad := range []string{"review", "review:none", "review:0"} {
+		if _, err := parseWIP(bad); err == nil {
+			t.Errorf("parseWIP(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWorkflowStateOf(t *testing.T) {
+	w := workflow{States: []string{"backlog", "doing", "done"}}
+
+	tests := []struct {
+		name string
+		todo Todo
+		want string
+	}{
+		{name: "never moved", todo: Todo{}, want: "backlog"},
+		{name: "never moved, done", todo: Todo{Done: true}, want: "done"},
+		{name: "moved", todo: Todo{State: "doing"}, want: "doing"},
+		{name: "done wins over state", todo: Todo{State: "doing", Done: true}, want: "done"},
+		{name: "terminal state but reopened", todo: Todo{State: "done"}, want: "backlog"},
+		{name: "state no longer in workflow", todo: Todo{State: "review"}, want: "backlog"},
+	}
+
+	for _, tt := range tests {
+		if got := w.stateOf(tt.todo); got != tt.want {
+			t.Errorf("%s: stateOf() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoveTodo(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+
+	id := int(insertTestTodo(t, "Write docs", PriorityMedium, "", ""))
+	get := func() *Todo {
+		todo, err := getTodoByID(id)
+		if err != nil {
+			t.Fatalf("getTodoByID() error = %v", err)
+		}
+		return todo
+	}
+
+	if err := moveTodo(id, "review"); err != nil {
+		t.Fatalf("moveTodo() error = %v", err)
+	}
+	if todo := get(); todo.State != "review" || todo.Done {
+		t.Errorf("after move to review: state = %q, done = %v", todo.State, todo.Done)
+	}
+
+	if err := moveTodo(id, "done"); err != nil {
+		t.Fatalf("moveTodo() error = %v", err)
+	}
+	if todo := get(); todo.State != "done" || !todo.Done || !todo.CompletedAt.Valid {
+		t.Errorf("after move to done: state = %q, done = %v, completed = %v", todo.State, todo.Done, todo.CompletedAt)
+	}
+
+	// undone goes back to the initial state
+	if err := markTodoAsUndone(id); err != nil {
+		t.Fatalf("markTodoAsUndone() error = %v", err)
+	}
+	if todo := get(); todo.State != "backlog" || todo.Done || todo.CompletedAt.Valid {
+		t.Errorf("after undone: state = %q, done = %v, completed = %v", todo.State, todo.Done, todo.CompletedAt)
+	}
+
+	// done goes to the terminal state
+	moveTodo(id, "in-progress")
+	if err := markTodoAsDone(id); err != nil {
+		t.Fatalf("markTodoAsDone() error = %v", err)
+	}
+	if todo := get(); todo.State != "done" || !todo.Done {
+		t.Errorf("after done: state = %q, done = %v", todo.State, todo.Done)
+	}
+
+	// and the whole move can be undone
+	if _, err := undoJournal(1); err != nil {
+		t.Fatalf("undoJournal() error = %v", err)
+	}
+	if todo := get(); todo.State != "in-progress" || todo.Done {
+		t.Errorf("after undo: state = %q, done = %v", todo.State, todo.Done)
+	}
+}
+
+func TestCmdMove(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	t.Setenv("TODO_WORKFLOW_WIP", "in-progress:1")
+
+	insertTestTodo(t, "One", PriorityMedium, "", "")
+	insertTestTodo(t, "Two", PriorityMedium, "", "")
+
+	out := captureOutput(func() {
+		if err := cmdMove(1, "in-progress"); err != nil {
+			t.Errorf("cmdMove() error = %v", err)
+		}
+	})
+	if strings.Contains(out, "WIP limit") {
+		t.Errorf("cmdMove() warned below the WIP limit:\n%s", out)
+	}
+
+	out = captureOutput(func() {
+		if err := cmdMove(2, "In-Progress"); err != nil {
+			t.Errorf("cmdMove() error = %v", err)
+		}
+	})
+	if !strings.Contains(out, "in-progress has 2 todos, over its WIP limit of 1") {
+		t.Errorf("cmdMove() should warn over the WIP limit:\n%s", out)
+	}
+
+	if err := cmdMove(1, "shipped"); err == nil || !strings.Contains(err.Error(), "unknown state") {
+		t.Errorf("cmdMove() to unknown state error = %v", err)
+	}
+	if err := cmdMove(99, "review"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("cmdMove() on missing todo error = %v", err)
+	}
+}
+
+func TestRenderBoard(t *testing.T) {
+	w := workflow{States: []string{"todo", "doing", "done"}, WIP: map[string]int{"doing": 1}}
+	recent := time.Now().Add(-time.Hour)
+	todos := []Todo{
+		{ID: 1, Title: "Plan the release notes for the next version", Priority: PriorityHigh},
+		{ID: 2, Title: "Fix login", Priority: PriorityMedium, State: "doing"},
+		{ID: 3, Title: "Fix logout", Priority: PriorityMedium, State: "doing"},
+		{ID: 4, Title: "Shipped", Priority: PriorityLow, Done: true},
+	}
+	todos[3].CompletedAt.Time, todos[3].CompletedAt.Valid = recent, true
+
+	lines := renderBoard(w, todos, 60, time.Time{})
+	plain := make([]string, len(lines))
+	for i, l := range lines {
+		plain[i] = stripAnsi(l)
+		if displayWidth(plain[i]) > 60 {
+			t.Errorf("renderBoard() line %d is %d cells wide, over 60: %q", i, displayWidth(plain[i]), plain[i])
+		}
+	}
+	out := strings.Join(plain, "\n")
+
+	for _, want := range []string{"TODO (1)", "DOING (2/1)", "DONE (1)", "#2 Fix login", "#4 Shipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderBoard() missing %q:\n%s", want, out)
+		}
+	}
+
+	// Columns sit side by side: headers on the first line
+	if !strings.HasPrefix(plain[0], "TODO (1)") || !strings.Contains(plain[0], "DOING") {
+		t.Errorf("renderBoard() header line = %q", plain[0])
+	}
+	// Long titles wrap inside their column
+	if !strings.Contains(plain[2], "#1 Plan the") || !strings.Contains(plain[3], "   ") {
+		t.Errorf("renderBoard() should wrap long titles:\n%s", out)
+	}
+
+	hidden := strings.Join(renderBoard(w, todos, 60, time.Now()), "\n")
+	if strings.Contains(hidden, "Shipped") {
+		t.Errorf("renderBoard() should hide done todos completed before the cutoff")
+	}
+}
+
+func TestCmdBoard(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB()
+	t.Setenv("COLUMNS", "80")
+	t.Setenv("TODO_WORKFLOW_WIP", "review:1")
+
+	insertTestTodo(t, "One", PriorityMedium, "work", "")
+	insertTestTodo(t, "Two", PriorityMedium, "work", "")
+	insertTestTodo(t, "Home chore", PriorityLow, "home", "")
+	moveTodo(1, "review")
+	moveTodo(2, "review")
+
+	var err error
+	out := stripAnsi(captureOutput(func() { err = cmdBoard("work", false) }))
+	if err != nil {
+		t.Fatalf("cmdBoard() error = %v", err)
+	}
+	if !strings.Contains(out, "REVIEW (2/1)") || strings.Contains(out, "Home chore") {
+		t.Errorf("cmdBoard() output:\n%s", out)
+	}
+	if !strings.Contains(out, "Warning: review has 2 todos, over its WIP limit of 1") {
+		t.Errorf("cmdBoard() should warn about the WIP limit:\n%s", out)
+	}
+}